@@ -0,0 +1,170 @@
+package health
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// Spring Boot Actuator statuses that do not map directly to Status constants
+const (
+	actuatorOutOfService = "OUT_OF_SERVICE"
+)
+
+// NewCheckfromActuatorJSON returns a Check populated from a Spring Boot Actuator /health JSON document.
+//
+// Each non-composite component becomes a Service, with nested composite components
+// named "parent.child". Numeric details become Metrics named "component.detail",
+// and all other details become Properties named the same way. UP and DOWN map directly,
+// OUT_OF_SERVICE maps to CRITICAL, and unrecognized statuses map to UNKNOWN. Actuator 1.x
+// documents, with components inline at the top level, are also understood.
+func NewCheckfromActuatorJSON(hcjson []byte) (Check, error) {
+	var hc = NewCheck()
+
+	jmap, err := jsonToMap(hcjson)
+	if err != nil {
+		return hc, err
+	}
+
+	if _, ok := jmap["status"]; !ok {
+		return hc, fmt.Errorf("document is not an actuator health document: no status")
+	}
+
+	components := actuatorComponents(&hc, "", jmap)
+	if components == 0 {
+		// Nothing to calculate from, so trust the document
+		hc.OverallStatus = actuatorStatus(cast.ToString(jmap["status"]))
+		return hc, nil
+	}
+
+	hc.Calculate()
+	return hc, nil
+}
+
+// actuatorComponents adds the children and details of the provided component to hc, returning
+// the number of leaf components found
+func actuatorComponents(hc *Check, name string, component map[string]interface{}) int {
+	children, details := actuatorChildren(component)
+
+	if len(children) == 0 && name != "" {
+		// Leaf component
+		hc.AddService(&Status{
+			Name:   name,
+			Status: actuatorStatus(cast.ToString(component["status"])),
+		})
+		actuatorDetails(hc, name, details)
+		return 1
+	}
+
+	actuatorDetails(hc, name, details)
+
+	var count int
+	for _, k := range sortedKeys(children) {
+		count += actuatorComponents(hc, joinName(name, k), cast.ToStringMap(children[k]))
+	}
+	return count
+}
+
+// actuatorChildren splits an actuator component into its child components and its details,
+// handling the Actuator 2.2+ "components", 2.0 "details", and 1.x inline layouts.
+func actuatorChildren(component map[string]interface{}) (children, details map[string]interface{}) {
+	if c, ok := component["components"]; ok {
+		return cast.ToStringMap(c), cast.ToStringMap(component["details"])
+	}
+
+	if d, ok := component["details"]; ok {
+		dmap := cast.ToStringMap(d)
+		if len(dmap) > 0 && allComponents(dmap) {
+			return dmap, nil
+		}
+		return nil, dmap
+	}
+
+	children = make(map[string]interface{})
+	details = make(map[string]interface{})
+	for k, v := range component {
+		if k == "status" {
+			continue
+		}
+		if isComponent(v) {
+			children[k] = v
+		} else {
+			details[k] = v
+		}
+	}
+	return children, details
+}
+
+// actuatorDetails adds numeric details as Metrics, and everything else as Properties
+func actuatorDetails(hc *Check, name string, details map[string]interface{}) {
+	for _, k := range sortedKeys(details) {
+		dname := joinName(name, k)
+		v := details[k]
+
+		switch v.(type) {
+		case map[string]interface{}, []interface{}, bool, nil:
+			hc.Properties[dname] = v
+			continue
+		}
+
+		if _, ok := isNumericGimme(cast.ToString(v)); ok {
+			hc.AddMetric(&Status{
+				Name:  dname,
+				Value: v,
+			})
+		} else {
+			hc.Properties[dname] = v
+		}
+	}
+}
+
+// actuatorStatus maps an actuator status onto the Status constants
+func actuatorStatus(status string) Severity {
+	if strings.EqualFold(status, actuatorOutOfService) {
+		return CRITICAL
+	}
+	if s, err := ParseSeverity(status); err == nil && s != "" {
+		return s
+	}
+	return UNKNOWN
+}
+
+// allComponents returns true if every value in the map is an actuator component
+func allComponents(m map[string]interface{}) bool {
+	for _, v := range m {
+		if !isComponent(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// isComponent returns true if the value looks like an actuator component
+func isComponent(v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = m["status"]
+	return ok
+}
+
+// joinName returns name and child joined by a period, or just child if name is empty
+func joinName(name, child string) string {
+	if name == "" {
+		return child
+	}
+	return name + "." + child
+}
+
+// sortedKeys returns the keys of the map, sorted
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,126 @@
+package health
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+
+	"testing"
+)
+
+var actuatorJSON = []byte(`{
+  "status": "DOWN",
+  "components": {
+    "db": {
+      "status": "UP",
+      "details": {
+        "database": "PostgreSQL",
+        "validationQuery": "isValid()"
+      }
+    },
+    "diskSpace": {
+      "status": "UP",
+      "details": {
+        "total": 10726932480,
+        "free": 9218899968,
+        "threshold": 10485760,
+        "exists": true
+      }
+    },
+    "caches": {
+      "status": "DOWN",
+      "components": {
+        "redis": {
+          "status": "DOWN",
+          "details": {
+            "error": "connection refused"
+          }
+        },
+        "local": {
+          "status": "UP"
+        }
+      }
+    },
+    "ping": {
+      "status": "UP"
+    }
+  }
+}`)
+
+var actuator1JSON = []byte(`{
+  "status": "UP",
+  "diskSpace": {
+    "status": "UP",
+    "total": 10726932480,
+    "free": 9218899968
+  },
+  "mongo": {
+    "status": "OUT_OF_SERVICE",
+    "version": "3.2.17"
+  }
+}`)
+
+func Test_NewCheckfromActuatorJSON(t *testing.T) {
+
+	Convey("When NewCheckfromActuatorJSON is called on an Actuator 2.x document", t, func() {
+		hc, err := NewCheckfromActuatorJSON(actuatorJSON)
+		So(err, ShouldBeNil)
+
+		Convey("leaf and nested composite components are Services", func() {
+			So(hc.OverallStatus, ShouldEqual, CRITICAL)
+			So(len(hc.Services), ShouldEqual, 5)
+
+			names := make(map[string]Severity)
+			for _, s := range hc.Services {
+				names[s.Name] = s.Status
+			}
+			So(names, ShouldResemble, map[string]Severity{
+				"caches.local": UP,
+				"caches.redis": DOWN,
+				"db":           UP,
+				"diskSpace":    UP,
+				"ping":         UP,
+			})
+		})
+
+		Convey("numeric details are Metrics, and others are Properties", func() {
+			So(len(hc.Metrics), ShouldEqual, 3)
+			So(hc.Metrics[0].Name, ShouldEqual, "diskSpace.free")
+			So(hc.Properties["db.database"], ShouldEqual, "PostgreSQL")
+			So(hc.Properties["diskSpace.exists"], ShouldEqual, true)
+			So(hc.Properties["caches.redis.error"], ShouldEqual, "connection refused")
+		})
+
+		Convey("the result can be merged and is valid", func() {
+			base := NewCheck()
+			base.Merge(&hc)
+			So(base.OverallStatus, ShouldEqual, CRITICAL)
+			So(hc.Validate(), ShouldBeNil)
+		})
+	})
+
+	Convey("When NewCheckfromActuatorJSON is called on an Actuator 1.x document, OUT_OF_SERVICE is CRITICAL", t, func() {
+		hc, err := NewCheckfromActuatorJSON(actuator1JSON)
+		So(err, ShouldBeNil)
+		So(hc.OverallStatus, ShouldEqual, CRITICAL)
+		So(len(hc.Services), ShouldEqual, 2)
+		So(len(hc.Metrics), ShouldEqual, 2)
+		So(hc.Properties["mongo.version"], ShouldEqual, "3.2.17")
+	})
+
+	Convey("When NewCheckfromActuatorJSON is called on a status-only document, the status is used", t, func() {
+		hc, err := NewCheckfromActuatorJSON([]byte(`{"status":"OUT_OF_SERVICE"}`))
+		So(err, ShouldBeNil)
+		So(hc.OverallStatus, ShouldEqual, CRITICAL)
+
+		hc, err = NewCheckfromActuatorJSON([]byte(`{"status":"MAINTENANCE"}`))
+		So(err, ShouldBeNil)
+		So(hc.OverallStatus, ShouldEqual, UNKNOWN)
+	})
+
+	Convey("When NewCheckfromActuatorJSON is called on something else, an error is returned", t, func() {
+		_, err := NewCheckfromActuatorJSON([]byte(`{"nope":true}`))
+		So(err, ShouldNotBeNil)
+
+		_, err = NewCheckfromActuatorJSON([]byte(`not json`))
+		So(err, ShouldNotBeNil)
+	})
+}
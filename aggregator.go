@@ -0,0 +1,215 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultUpstreamTimeout is the amount of time an Aggregator waits for an Upstream, unless otherwise specified
+const DefaultUpstreamTimeout = 5 * time.Second
+
+// maxUpstreamBody is the maximum number of bytes read from an Upstream
+const maxUpstreamBody = 10 << 20
+
+// Upstream is a named source of go-health JSON
+type Upstream struct {
+	// Name is used to prefix everything from the Upstream when it is merged
+	Name string
+	// URL is fetched with a GET
+	URL string
+	// Timeout overrides the Aggregator Timeout for this Upstream
+	Timeout time.Duration
+}
+
+// cachedCheck is a fetched Check and when it should be discarded
+type cachedCheck struct {
+	check   Check
+	expires time.Time
+}
+
+// flight is an in-progress fetch of an Upstream, shared by everyone who wants it
+type flight struct {
+	done  chan struct{}
+	check Check
+}
+
+// Aggregator fetches Checks from Upstreams concurrently, and merges them into one Check.
+// Upstreams that cannot be fetched, or time out, are CRITICAL services. Upstreams that
+// return something other than go-health JSON are UNKNOWN services. Either is named
+// "<Upstream.Name>_upstream", with the reason as its Error. Concurrent fetches of the same
+// Upstream are collapsed into one. Fetches are cached and collapsed by URL, so Upstreams with
+// the same Name do not overwrite each other, and Upstreams with the same URL share a fetch.
+type Aggregator struct {
+	// Upstreams is the list of Upstreams to fetch
+	Upstreams []Upstream
+	// TTL is the amount of time a fetched Check is cached for. If 0, nothing is cached.
+	// Failures are never cached, so a transient failure lasts only until the next Check
+	TTL time.Duration
+	// Timeout is the amount of time to wait for each Upstream, unless the Upstream specifies its own
+	Timeout time.Duration
+	// Client is used to fetch Upstreams. Defaults to http.DefaultClient
+	Client *http.Client
+
+	lock     sync.Mutex
+	cache    map[string]cachedCheck
+	inflight map[string]*flight
+}
+
+// NewAggregator returns an Aggregator for the provided Upstreams, caching results for ttl
+func NewAggregator(ttl time.Duration, upstreams ...Upstream) *Aggregator {
+	return &Aggregator{
+		Upstreams: upstreams,
+		TTL:       ttl,
+		Timeout:   DefaultUpstreamTimeout,
+		cache:     make(map[string]cachedCheck),
+	}
+}
+
+// Check fetches every Upstream that is not cached, concurrently, and returns a Check with all
+// of them PrefixedMerge()d, in Upstream order
+func (a *Aggregator) Check(ctx context.Context) Check {
+	checks := make([]Check, len(a.Upstreams))
+
+	var wg sync.WaitGroup
+	for i := range a.Upstreams {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			checks[i] = a.get(ctx, a.Upstreams[i])
+		}(i)
+	}
+	wg.Wait()
+
+	hc := NewCheck()
+	for i := range checks {
+		hc.PrefixedMerge(a.Upstreams[i].Name, &checks[i])
+	}
+	hc.Calculate()
+	return hc
+}
+
+// get returns a copy of the cached Check for the Upstream, the result of a fetch of it that is
+// already in progress, or fetches it
+func (a *Aggregator) get(ctx context.Context, up Upstream) Check {
+	now := time.Now()
+
+	a.lock.Lock()
+	if a.cache == nil {
+		a.cache = make(map[string]cachedCheck)
+	}
+	if a.inflight == nil {
+		a.inflight = make(map[string]*flight)
+	}
+	if c, ok := a.cache[up.URL]; ok && a.TTL > 0 && now.Before(c.expires) {
+		a.lock.Unlock()
+		return copyCheck(&c.check)
+	}
+	if f, ok := a.inflight[up.URL]; ok {
+		a.lock.Unlock()
+
+		select {
+		case <-f.done:
+			return copyCheck(&f.check)
+		case <-ctx.Done():
+			return upstreamFailure(CRITICAL, fmt.Sprintf("fetch failed: %v", ctx.Err()))
+		}
+	}
+	f := &flight{done: make(chan struct{})}
+	a.inflight[up.URL] = f
+	a.lock.Unlock()
+
+	hc, ok := a.fetch(ctx, up)
+
+	a.lock.Lock()
+	if ok && a.TTL > 0 {
+		a.cache[up.URL] = cachedCheck{
+			check:   copyCheck(&hc),
+			expires: now.Add(a.TTL),
+		}
+	}
+	f.check = copyCheck(&hc)
+	delete(a.inflight, up.URL)
+	a.lock.Unlock()
+	close(f.done)
+
+	return hc
+}
+
+// fetch retrieves the Upstream, returning the resulting Check and true, or a Check with a single
+// CRITICAL or UNKNOWN service explaining what went wrong and false
+func (a *Aggregator) fetch(ctx context.Context, up Upstream) (Check, bool) {
+	timeout := up.Timeout
+	if timeout <= 0 {
+		timeout = a.Timeout
+	}
+	if timeout <= 0 {
+		timeout = DefaultUpstreamTimeout
+	}
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, up.URL, nil)
+	if err != nil {
+		return upstreamFailure(CRITICAL, fmt.Sprintf("bad request: %v", err)), false
+	}
+	req.Header.Set("Accept", JSONContentType)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return upstreamFailure(CRITICAL, fmt.Sprintf("fetch failed: %v", err)), false
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxUpstreamBody))
+	if err != nil {
+		return upstreamFailure(CRITICAL, fmt.Sprintf("read failed: %v", err)), false
+	}
+
+	hc, err := NewCheckfromJSON(body)
+	if err == nil {
+		if jmap, _ := jsonToMap(body); jmap["overallStatus"] == nil {
+			err = fmt.Errorf("no overallStatus")
+		}
+	}
+	if err != nil {
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return upstreamFailure(CRITICAL, fmt.Sprintf("unexpected status: %s", resp.Status)), false
+		}
+		return upstreamFailure(UNKNOWN, fmt.Sprintf("invalid healthcheck: %v", err)), false
+	}
+
+	return hc, true
+}
+
+// upstreamFailure returns a Check with a single service representing the failed Upstream.
+// Once PrefixedMerge()d, the service is named "<Upstream.Name>_upstream".
+func upstreamFailure(status Severity, reason string) Check {
+	hc := NewCheck()
+	hc.AddService(&Status{
+		Name:   "upstream",
+		Status: status,
+		Error:  reason,
+	})
+	hc.Calculate()
+	return hc
+}
+
+// copyCheck returns a copy of hc, with its own Services, Systems, and Metrics, so the
+// copy can be PrefixedMerge()d without affecting the original
+func copyCheck(hc *Check) Check {
+	c := *hc
+	c.Services = append([]Status(nil), hc.Services...)
+	c.Systems = append([]Status(nil), hc.Systems...)
+	c.Metrics = append([]Status(nil), hc.Metrics...)
+	return c
+}
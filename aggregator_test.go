@@ -0,0 +1,159 @@
+package health
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_Aggregator(t *testing.T) {
+
+	Convey("When an Aggregator has a variety of Upstreams", t, func() {
+		var hits int32
+		good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			w.Write(apiJSON)
+		}))
+		defer good.Close()
+
+		down := httptest.NewServer(NewHandler(func() Check {
+			hc := NewCheck()
+			hc.AddService(&Status{Name: "db", Status: CRITICAL})
+			return hc
+		}))
+		defer down.Close()
+
+		garbage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("<html>hello</html>"))
+		}))
+		defer garbage.Close()
+
+		broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+		}))
+		defer broken.Close()
+
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		}))
+		defer slow.Close()
+
+		a := NewAggregator(time.Minute,
+			Upstream{Name: "good", URL: good.URL},
+			Upstream{Name: "down", URL: down.URL},
+			Upstream{Name: "garbage", URL: garbage.URL},
+			Upstream{Name: "broken", URL: broken.URL},
+			Upstream{Name: "slow", URL: slow.URL, Timeout: 20 * time.Millisecond},
+		)
+
+		hc := a.Check(context.Background())
+		statuses := make(map[string]Severity)
+		for _, s := range append(hc.Services, hc.Systems...) {
+			statuses[s.Name] = s.Status
+		}
+
+		Convey("good Upstreams are prefix-merged", func() {
+			So(statuses["good_DBConnection"], ShouldEqual, OK)
+			So(statuses["down_db"], ShouldEqual, CRITICAL)
+		})
+
+		Convey("failures are services named for the Upstream", func() {
+			So(statuses["garbage_upstream"], ShouldEqual, UNKNOWN)
+			So(statuses["broken_upstream"], ShouldEqual, CRITICAL)
+			So(statuses["slow_upstream"], ShouldEqual, CRITICAL)
+			So(hc.OverallStatus, ShouldEqual, CRITICAL)
+		})
+
+		Convey("results are cached, and names are not re-prefixed", func() {
+			hc2 := a.Check(context.Background())
+			So(atomic.LoadInt32(&hits), ShouldEqual, 1)
+			So(hc2, ShouldResemble, hc)
+		})
+
+		Convey("results are refetched after the TTL", func() {
+			a.TTL = 0
+			a.Check(context.Background())
+			a.Check(context.Background())
+			So(atomic.LoadInt32(&hits), ShouldEqual, 3)
+		})
+	})
+
+	Convey("When an Upstream fails, the failure is not cached", t, func() {
+		var hits int32
+		flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&hits, 1) == 1 {
+				http.Error(w, "bad gateway", http.StatusBadGateway)
+				return
+			}
+			w.Write(apiJSON)
+		}))
+		defer flaky.Close()
+
+		a := NewAggregator(time.Minute, Upstream{Name: "flaky", URL: flaky.URL})
+		So(a.Check(context.Background()).OverallStatus, ShouldEqual, CRITICAL)
+		So(a.Check(context.Background()).Systems[0].Name, ShouldEqual, "flaky_DBConnection")
+		a.Check(context.Background())
+		So(atomic.LoadInt32(&hits), ShouldEqual, 2)
+	})
+
+	Convey("When an Upstream is fetched concurrently, there is only one fetch", t, func() {
+		var hits int32
+		release := make(chan struct{})
+		blocked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			<-release
+			w.Write(apiJSON)
+		}))
+		defer blocked.Close()
+
+		// Callers that miss the fetch get the cached result, so any other fetch is a failure to collapse
+		up := Upstream{Name: "blocked", URL: blocked.URL}
+		a := NewAggregator(time.Minute, up)
+
+		const n = 5
+		results := make(chan Check, n)
+		for i := 0; i < n; i++ {
+			go func() {
+				results <- a.get(context.Background(), up)
+			}()
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+
+		for i := 0; i < n; i++ {
+			hc := <-results
+			So(hc.Systems[0].Name, ShouldEqual, "DBConnection")
+		}
+		So(atomic.LoadInt32(&hits), ShouldEqual, 1)
+	})
+
+	Convey("When Upstreams share a Name, their results are not confused", t, func() {
+		down := httptest.NewServer(NewHandler(func() Check {
+			hc := NewCheck()
+			hc.AddService(&Status{Name: "db", Status: CRITICAL})
+			return hc
+		}))
+		defer down.Close()
+		good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write(apiJSON)
+		}))
+		defer good.Close()
+
+		a := NewAggregator(time.Minute, Upstream{Name: "app", URL: down.URL}, Upstream{Name: "app", URL: good.URL})
+		for i := 0; i < 2; i++ {
+			hc := a.Check(context.Background())
+			So(len(hc.Services), ShouldEqual, 1)
+			So(hc.Services[0].Name, ShouldEqual, "app_db")
+			So(hc.Systems[0].Name, ShouldEqual, "app_DBConnection")
+			So(hc.OverallStatus, ShouldEqual, CRITICAL)
+		}
+	})
+}
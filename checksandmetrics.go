@@ -10,6 +10,10 @@ import (
 	"github.com/spf13/cast"
 )
 
+// DefaultMaxMetricDepth is the number of levels of nested metric maps that Metrics and
+// NewCheckfromJSON flatten into child metrics. Maps nested deeper are ignored.
+const DefaultMaxMetricDepth = 4
+
 var (
 	safeReplacer = strings.NewReplacer(
 		".", ":",
@@ -17,6 +21,11 @@ var (
 		"-", "_",
 		"%", "perc",
 	)
+	// metricKeyReplacer makes SafeLabel'd keys safe in Performance Data labels, too
+	metricKeyReplacer = strings.NewReplacer(
+		"=", "_",
+		"'", "_",
+	)
 )
 
 // SafeLabel returns a label that is safe to use with modern RRD
@@ -25,9 +34,11 @@ func SafeLabel(label string) string {
 }
 
 // Metrics takes a "metrics" document and appends Nagios-compatible metrics
-// information to the message
+// information to the message. Metrics with map values are flattened into child
+// metrics, as by FlattenMetrics with DefaultMaxMetricDepth. To flatten to another depth,
+// FlattenMetrics the document first.
 func Metrics(n *nagios.Nagios, checkMap []interface{}, noisy bool) {
-	for _, r := range checkMap {
+	for _, r := range FlattenMetrics(checkMap, DefaultMaxMetricDepth) {
 		jr := lcKeys(cast.ToStringMap(r))
 		// We check to see if there is a value, and that it is
 		// numeric before adding it
@@ -45,72 +56,127 @@ func Metrics(n *nagios.Nagios, checkMap []interface{}, noisy bool) {
 
 				// Casts
 				var (
-					warn string
-					crit string
-					min  string
-					max  string
-					name string
+					warn      interface{}
+					crit      interface{}
+					warnUnder interface{}
+					critUnder interface{}
+					min       string
+					max       string
+					name      string
 				)
 
 				if _, ok := jr["warnover"]; ok {
-					warn = cast.ToString(jr["warnover"])
+					warn = jr["warnover"]
 				} else {
-					warn = cast.ToString(jr["warnvalue"])
+					warn = jr["warnvalue"]
 				}
 
 				if _, ok := jr["badover"]; ok {
-					crit = cast.ToString(jr["badover"])
+					crit = jr["badover"]
 				} else {
-					crit = cast.ToString(jr["badvalue"])
+					crit = jr["badvalue"]
 				}
 
+				warnUnder = jr["warnunder"]
+				critUnder = jr["badunder"]
+				importance := cast.ToString(jr["importance"])
 				min = cast.ToString(jr["minvalue"])
 				max = cast.ToString(jr["maxvalue"])
 				name = cast.ToString(jr["name"])
 
-				n.AddMetricNumbers(name, value, warn, crit, min, max)
+				perfValue := value
+				if uom := cast.ToString(jr["suffix"]); ValidUOM(uom) {
+					perfValue += uom
+				}
+
+				n.AddMetricNumbers(name, perfValue, perfThreshold(warn, warnUnder), perfThreshold(crit, critUnder), min, max)
 
 				if _, ok := jr["status"]; ok {
-					status := cast.ToString(jr["status"])
+					status, shown := parseShownSeverity(jr["status"])
 
-					n.AddMessageIfBool(fmt.Sprintf(" %s %s=%s", status, name, value), noisy || status != "OK")
-					switch status {
-					case WARNING:
-						n.EscalateIf(nagios.WARNING)
-					case BAD, ERROR, CRITICAL, DOWN:
-						n.EscalateIf(nagios.CRITICAL)
+					n.AddMessageIfBool(fmt.Sprintf(" %s %s=%s", shown, name, value), noisy || !status.IsOK())
+					switch status.Canonical() {
+					case WARNING, CRITICAL:
+						escalateIf(n, status.NagiosCode(), importance)
 					}
 
 				} else if v, ok := isNumericGimme(value); ok {
-					// No status declared, but value is a number, so lets see what we got with the other numbers
+					// No status declared, but value is a number, so lets see what we got with the thresholds
 
-					if cv, ok := isNumericGimme(crit); ok && v > cv {
+					switch thresholdStatus(v, warn, crit, warnUnder, critUnder) {
+					case CRITICAL:
 						n.AddMessage(fmt.Sprintf(" %s %s=%s", CRITICAL, name, value))
-						n.EscalateIf(nagios.CRITICAL)
-					} else if wv, ok := isNumericGimme(warn); ok && v > wv {
+						escalateIf(n, nagios.CRITICAL, importance)
+					case WARNING:
 						n.AddMessage(fmt.Sprintf(" %s %s=%s", WARNING, name, value))
-						n.EscalateIf(nagios.WARNING)
+						escalateIf(n, nagios.WARNING, importance)
 					}
 				}
 			case map[string]interface{}:
-				// TODO: recurse maps?
-				// TODO: Handle geopoints?
+				if _, ok := ParseGeoPoint(val); ok {
+					geoMetric(n, jr, noisy)
+				}
+				// Otherwise nested too deeply to be flattened
 			}
 
 		}
 	}
 }
 
+// FlattenMetrics takes a "metrics" document and returns it with every metric whose value is a
+// map replaced by a child metric for each key, in order. Each child is named "<parent>.<key>",
+// with each "."-separated part of the key SafeLabel'd, and "=" and "'" replaced, and inherits everything but the name and value from its parent, including thresholds. Up to
+// maxDepth levels of nested maps are flattened, and maps nested deeper are left as-is.
+func FlattenMetrics(checkMap []interface{}, maxDepth int) []interface{} {
+	flat := make([]interface{}, 0, len(checkMap))
+	for _, r := range checkMap {
+		flat = flattenMetric(flat, r, 0, maxDepth)
+	}
+	return flat
+}
+
+// flattenMetric appends the metric to flat, or its children if its value is a map
+func flattenMetric(flat []interface{}, r interface{}, depth, maxDepth int) []interface{} {
+	jr := lcKeys(cast.ToStringMap(r))
+	children, ok := jr["value"].(map[string]interface{})
+	if _, isPoint := ParseGeoPoint(children); !ok || isPoint || depth >= maxDepth {
+		return append(flat, r)
+	}
+
+	name := cast.ToString(jr["name"])
+	for _, k := range sortedKeys(children) {
+		child := make(map[string]interface{}, len(jr))
+		for ck, cv := range jr {
+			child[ck] = cv
+		}
+		child["name"] = name + "." + safeMetricKey(k)
+		child["value"] = children[k]
+		flat = flattenMetric(flat, child, depth+1, maxDepth)
+	}
+	return flat
+}
+
+// safeMetricKey returns the key of a nested metric map with each "."-separated part made safe
+func safeMetricKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i := range parts {
+		parts[i] = metricKeyReplacer.Replace(SafeLabel(parts[i]))
+	}
+	return strings.Join(parts, ".")
+}
+
 // Checks takes a status document, escalate the status, and appends
-// Nagios-compatible information to the message
+// Nagios-compatible information to the message. Items with an "importance" of
+// "degraded" escalate to at most WARNING, and "informational" items do not escalate.
 func Checks(n *nagios.Nagios, maxAge int64, checkMap []interface{}, noisy bool) {
 
 	now := time.Now().UnixMilli()
 	for _, r := range checkMap {
 		jr := lcKeys(cast.ToStringMap(r))
 
-		// Cache the name for easier use later
+		// Cache the name and importance for easier use later
 		checkName := cast.ToString(jr["name"])
+		importance := cast.ToString(jr["importance"])
 
 		// For an item, if a timeout has been defined, use it. Else use maxage
 		lMaxAge := maxAge * 1000
@@ -123,14 +189,14 @@ func Checks(n *nagios.Nagios, maxAge int64, checkMap []interface{}, noisy bool)
 		if timestamp, ok := jr["timestamp"]; ok && cast.ToInt64(timestamp) != 0 {
 			old := now - cast.ToInt64(timestamp)
 			if old > lMaxAge {
-				n.EscalateIf(nagios.WARNING)
+				escalateIf(n, nagios.WARNING, importance)
 				n.AddMessage(fmt.Sprintf(" %s: STALE (%d seconds old) ", checkName, old))
 			}
 		}
 
 		// Check the status
 		value := cast.ToString(jr["value"])
-		status := cast.ToString(jr["status"])
+		status, shown := parseShownSeverity(jr["status"])
 		errorMessage := cast.ToString(jr["error"])
 		message := cast.ToString(jr["message"])
 
@@ -139,48 +205,113 @@ func Checks(n *nagios.Nagios, maxAge int64, checkMap []interface{}, noisy bool)
 		}
 
 		// We're muting OK messages normally
-		n.AddMessageIfBool(fmt.Sprintf(" %s: %s", checkName, status), noisy || !isOK(status))
+		n.AddMessageIfBool(fmt.Sprintf(" %s: %s", checkName, shown), noisy || !status.IsOK())
 
-		switch status {
-		case WARNING:
-			n.EscalateIf(nagios.WARNING)
-			n.AddMessageIf(fmt.Sprintf(" %s", errorMessage), errorMessage)
-			n.AddMessageIf(fmt.Sprintf(" (%s)", message), message)
-		case BAD:
-			fallthrough
-		case ERROR:
-			fallthrough
-		case DOWN:
-			fallthrough
-		case CRITICAL:
-			n.EscalateIf(nagios.CRITICAL)
-			n.AddMessageIf(fmt.Sprintf(" %s", errorMessage), errorMessage)
-			n.AddMessageIf(fmt.Sprintf(" (%s)", message), message)
-		case UP:
-			fallthrough
+		if status == "" {
+			n.AddMessageIf(message, message)
+			continue
+		}
+
+		switch status.Canonical() {
 		case OK:
 			n.EscalateIf(nagios.OK)
 			n.AddMessageIfBool(fmt.Sprintf(" (%s)", message), noisy && message != "")
 		case UNKNOWN:
-			n.EscalateIf(nagios.UNKNOWN)
+			escalateIf(n, status.NagiosCode(), importance)
 			n.AddMessage(" Unknown state! ")
 			n.AddMessageIf(fmt.Sprintf(" %s", errorMessage), errorMessage)
 			n.AddMessageIf(fmt.Sprintf(" (%s)", message), message)
 		default:
-			n.AddMessageIf(message, message)
+			escalateIf(n, status.NagiosCode(), importance)
+			n.AddMessageIf(fmt.Sprintf(" %s", errorMessage), errorMessage)
+			n.AddMessageIf(fmt.Sprintf(" (%s)", message), message)
 		}
 	}
 }
 
+// geoMetric appends Nagios-compatible information about a metric with a GeoPoint value to
+// the message. If it has a GeoPoint expectedValue, its distance from that is the metric, and
+// is compared against the thresholds.
+func geoMetric(n *nagios.Nagios, jr map[string]interface{}, noisy bool) {
+	st := StatusSliceFromJmap([]interface{}{jr})[0]
+	distance, hasDistance := st.geoDistance()
+	if hasDistance {
+		n.AddMetrics(st.MetricString())
+	}
+
+	status := st.Status.Canonical()
+	if st.Status == "" {
+		status = st.valueStatus()
+	}
+
+	msg := fmt.Sprintf(" %s %s=%s", status, st.Name, st.Value)
+	if hasDistance {
+		msg = fmt.Sprintf("%s (%.3fkm from %s)", msg, distance, st.ExpectedValue)
+	}
+	n.AddMessageIfBool(msg, noisy || (status != "" && status != OK))
+
+	switch status {
+	case WARNING, CRITICAL:
+		escalateIf(n, status.NagiosCode(), st.Importance)
+	}
+}
+
+// StatusChecks is Checks, for Services or Systems that are already Statuses
+func StatusChecks(n *nagios.Nagios, maxAge int64, statuses []Status, noisy bool) {
+	Checks(n, maxAge, statusesToJmap(statuses), noisy)
+}
+
+// StatusMetrics is Metrics, for Metrics that are already Statuses
+func StatusMetrics(n *nagios.Nagios, statuses []Status, noisy bool) {
+	Metrics(n, statusesToJmap(statuses), noisy)
+}
+
+// statusesToJmap returns the Statuses as they would be decoded from JSON. Statuses that
+// cannot be encoded are skipped.
+func statusesToJmap(statuses []Status) []interface{} {
+	jmap := make([]interface{}, 0, len(statuses))
+	for i := range statuses {
+		b, err := statuses[i].MarshalJSON()
+		if err != nil {
+			continue
+		}
+		m, err := jsonToMap(b)
+		if err != nil {
+			continue
+		}
+		jmap = append(jmap, map[string]interface{}(m))
+	}
+	return jmap
+}
+
+// parseShownSeverity returns the Severity of the raw status, as ParseSeverity does, and how
+// to show it in a message: as parsed, or as-is if it is unrecognized, so the original
+// is not lost
+func parseShownSeverity(raw interface{}) (Severity, string) {
+	rs := cast.ToString(raw)
+	status, err := ParseSeverity(rs)
+	if err != nil {
+		return status, rs
+	}
+	return status, string(status)
+}
+
+// escalateIf escalates n to code, unless the importance prevents it. Informational
+// items never escalate beyond OK, and degraded items never escalate beyond WARNING.
+func escalateIf(n *nagios.Nagios, code int, importance string) {
+	switch strings.ToLower(importance) {
+	case ImportanceInformational:
+		return
+	case ImportanceDegraded:
+		if code == nagios.CRITICAL {
+			code = nagios.WARNING
+		}
+	}
+	n.EscalateIf(code)
+}
+
 // Test to see if a string is a valid number type, and return a float64 of it if so
 func isNumericGimme(s string) (float64, bool) {
 	v, err := strconv.ParseFloat(s, 64)
 	return v, err == nil
 }
-
-func isOK(status string) bool {
-	if status == OK || status == UP {
-		return true
-	}
-	return false
-}
@@ -104,3 +104,131 @@ func Test_ChecksEscalate(t *testing.T) {
 		So(newNag.Status(), ShouldEqual, nagios.CRITICAL)
 	})
 }
+
+func Test_ChecksImportance(t *testing.T) {
+	checks := []interface{}{
+		map[string]interface{}{
+			"name":       "recommendations",
+			"status":     "DOWN",
+			"importance": "degraded",
+		},
+		map[string]interface{}{
+			"name":       "build",
+			"status":     "CRITICAL",
+			"importance": "informational",
+		},
+	}
+	Convey("When a checks document has optional items, they do not escalate to CRITICAL", t, func() {
+		var newNag nagios.Nagios
+		Checks(&newNag, 0, checks, false)
+		So(newNag.Status(), ShouldEqual, nagios.WARNING)
+		So(newNag.Message, ShouldContainSubstring, "build: CRITICAL")
+
+		var infoNag nagios.Nagios
+		Checks(&infoNag, 0, checks[1:], false)
+		So(infoNag.Status(), ShouldEqual, nagios.OK)
+	})
+
+	Convey("When a metrics document has optional items, they do not escalate to CRITICAL", t, func() {
+		metrics := []interface{}{
+			map[string]interface{}{
+				"name":       "heap",
+				"value":      100,
+				"badOver":    10,
+				"importance": "degraded",
+			},
+		}
+		var newNag nagios.Nagios
+		Metrics(&newNag, metrics, false)
+		So(newNag.Status(), ShouldEqual, nagios.WARNING)
+	})
+}
+
+func Test_ChecksAliases(t *testing.T) {
+
+	Convey("When a metric is reported with an alias of OK, it is quiet", t, func() {
+		var n nagios.Nagios
+		Metrics(&n, []interface{}{
+			map[string]interface{}{"name": "conns", "value": 3, "status": "up"},
+		}, false)
+		So(n.Message, ShouldBeEmpty)
+		So(n.Status(), ShouldEqual, nagios.OK)
+	})
+
+	Convey("When an item has an unrecognized status, the original is shown", t, func() {
+		var n nagios.Nagios
+		Checks(&n, 300, []interface{}{
+			map[string]interface{}{"name": "db", "status": "sideways"},
+		}, false)
+		So(n.Message, ShouldContainSubstring, "db: sideways")
+		So(n.Message, ShouldContainSubstring, "Unknown state!")
+
+		n = nagios.Nagios{}
+		Metrics(&n, []interface{}{
+			map[string]interface{}{"name": "conns", "value": 3, "status": "sideways"},
+		}, false)
+		So(n.Message, ShouldContainSubstring, "sideways conns=3")
+	})
+}
+
+func Test_StatusChecks(t *testing.T) {
+
+	Convey("When Statuses are checked, their Message and Error are in the output", t, func() {
+		statuses := []Status{
+			{Name: "db", Status: DOWN, Error: "connection refused", Message: "failing over"},
+			{Name: "cache", Status: UP},
+		}
+
+		var n nagios.Nagios
+		StatusChecks(&n, 0, statuses, false)
+		So(n.Status(), ShouldEqual, nagios.CRITICAL)
+		So(n.Message, ShouldContainSubstring, "db: DOWN connection refused (failing over)")
+		So(n.Message, ShouldNotContainSubstring, "cache")
+	})
+
+	Convey("When metric Statuses are checked, they are rendered as metrics", t, func() {
+		var n nagios.Nagios
+		StatusMetrics(&n, []Status{{Name: "heap", Value: 95, WarnOver: 80, BadOver: 90}}, false)
+		So(n.Status(), ShouldEqual, nagios.CRITICAL)
+		So(n.Metrics, ShouldResemble, []string{"'heap'=95;80;90;;"})
+	})
+}
+
+func Test_MetricsNested(t *testing.T) {
+	metrics := []interface{}{
+		map[string]interface{}{
+			"name":     "memory",
+			"warnOver": 100,
+			"value": map[string]interface{}{
+				"heap":    123,
+				"nonheap": 45,
+				"pools": map[string]interface{}{
+					"eden.space": 7,
+				},
+				"non heap": 8,
+				"a=b's":    9,
+			},
+		},
+	}
+
+	Convey("When a metrics document has nested maps, they are flattened into child metrics", t, func() {
+		var n nagios.Nagios
+		Metrics(&n, metrics, false)
+		So(n.Status(), ShouldEqual, nagios.WARNING)
+		So(n.Metrics, ShouldResemble, []string{
+			"'memory.a_b_s'=9;100;;;",
+			"'memory.heap'=123;100;;;",
+			"'memory.non_heap'=8;100;;;",
+			"'memory.nonheap'=45;100;;;",
+			"'memory.pools.eden.space'=7;100;;;",
+		})
+	})
+
+	Convey("When nested maps are deeper than the maximum depth, they are left as-is", t, func() {
+		flat := FlattenMetrics(metrics, 1)
+		So(flat, ShouldHaveLength, 5)
+		So(flat[1].(map[string]interface{})["name"], ShouldEqual, "memory.heap")
+		So(flat[4].(map[string]interface{})["name"], ShouldEqual, "memory.pools")
+		So(flat[4].(map[string]interface{})["value"], ShouldResemble, map[string]interface{}{"eden.space": 7})
+	})
+}
@@ -0,0 +1,237 @@
+// check_gohealth is a Nagios-compatible plugin that fetches a go-health JSON document from a URL
+// or file, and checks the services, systems, and metrics therein.
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+
+	health "github.com/cognusion/go-health"
+	nagios "github.com/cognusion/go-nagios-checks"
+	"github.com/spf13/cast"
+)
+
+// maxBody is the maximum number of bytes read from a URL
+const maxBody = 10 << 20
+
+// config is the parsed command-line
+type config struct {
+	url      string
+	file     string
+	maxAge   int64
+	noisy    bool
+	timeout  time.Duration
+	insecure bool
+	caCert   string
+	cert     string
+	key      string
+	include  *regexp.Regexp
+	exclude  *regexp.Regexp
+}
+
+func main() {
+	n := run(os.Args[1:], os.Stderr)
+	n.Exit()
+}
+
+// run parses the arguments, fetches and checks the document, and returns the result
+func run(args []string, stderr io.Writer) *nagios.Nagios {
+	var n nagios.Nagios
+
+	conf, err := parseFlags(args, stderr)
+	if err != nil {
+		n.Code = nagios.UNKNOWN
+		n.AddMessage(err.Error())
+		return &n
+	}
+
+	body, err := fetch(conf)
+	if err != nil {
+		n.Code = nagios.CRITICAL
+		n.AddMessage(err.Error())
+		return &n
+	}
+
+	jmap := make(map[string]interface{})
+	if err := json.Unmarshal(body, &jmap); err != nil {
+		n.Code = nagios.UNKNOWN
+		n.AddMessage(fmt.Sprintf("invalid JSON: %v", err))
+		return &n
+	}
+	overall := cast.ToString(jmap["overallStatus"])
+	if overall == "" {
+		n.Code = nagios.UNKNOWN
+		n.AddMessage("not a go-health document: no overallStatus")
+		return &n
+	}
+
+	var total, matched int
+	sections := make(map[string][]interface{})
+	for _, k := range []string{"services", "systems", "metrics"} {
+		total += len(cast.ToSlice(jmap[k]))
+		sections[k] = filter(conf, jmap[k])
+		matched += len(sections[k])
+	}
+
+	filtered := conf.include != nil || conf.exclude != nil
+	if filtered && matched == 0 {
+		// The overallStatus is about items that were not checked
+		n.Code = nagios.UNKNOWN
+		n.AddMessage(fmt.Sprintf("nothing matched filters, of %d items", total))
+		return &n
+	}
+	if total == 0 {
+		// Nothing to check, so trust the document
+		status, _ := health.ParseSeverity(overall)
+		n.Code = status.NagiosCode()
+		n.AddMessage(fmt.Sprintf("overallStatus %s, with no items", overall))
+		return &n
+	}
+
+	health.Checks(&n, conf.maxAge, sections["services"], conf.noisy)
+	health.Checks(&n, conf.maxAge, sections["systems"], conf.noisy)
+	health.Metrics(&n, sections["metrics"], conf.noisy)
+
+	if n.Message == "" {
+		if filtered {
+			n.AddMessage(fmt.Sprintf("%d of %d items matched filters, all OK", matched, total))
+		} else {
+			n.AddMessage(fmt.Sprintf("overallStatus %s", overall))
+		}
+	}
+	return &n
+}
+
+// parseFlags parses the arguments into a config
+func parseFlags(args []string, stderr io.Writer) (*config, error) {
+	var (
+		conf    config
+		include string
+		exclude string
+	)
+
+	fs := flag.NewFlagSet("check_gohealth", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&conf.url, "url", "", "URL to fetch the go-health JSON from")
+	fs.StringVar(&conf.file, "file", "", "File to read the go-health JSON from")
+	fs.Int64Var(&conf.maxAge, "maxage", 300, "Number of seconds a timestamped item may age before it is stale, unless it declares a timeout")
+	fs.BoolVar(&conf.noisy, "noisy", false, "Include OK items in the message")
+	fs.DurationVar(&conf.timeout, "timeout", 10*time.Second, "Amount of time to wait for the URL")
+	fs.BoolVar(&conf.insecure, "insecure", false, "Skip verification of the URL's TLS certificate")
+	fs.StringVar(&conf.caCert, "cacert", "", "PEM file of CA certificates to verify the URL's TLS certificate with")
+	fs.StringVar(&conf.cert, "cert", "", "PEM file of the TLS client certificate to present")
+	fs.StringVar(&conf.key, "key", "", "PEM file of the TLS client key")
+	fs.StringVar(&include, "include", "", "Regular expression of item names to check. All are checked if unset")
+	fs.StringVar(&exclude, "exclude", "", "Regular expression of item names to skip")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if (conf.url == "") == (conf.file == "") {
+		return nil, fmt.Errorf("exactly one of --url or --file must be specified")
+	}
+	if (conf.cert == "") != (conf.key == "") {
+		return nil, fmt.Errorf("--cert and --key must be specified together")
+	}
+
+	var err error
+	if include != "" {
+		if conf.include, err = regexp.Compile(include); err != nil {
+			return nil, fmt.Errorf("invalid --include: %w", err)
+		}
+	}
+	if exclude != "" {
+		if conf.exclude, err = regexp.Compile(exclude); err != nil {
+			return nil, fmt.Errorf("invalid --exclude: %w", err)
+		}
+	}
+
+	return &conf, nil
+}
+
+// fetch returns the document from the file or URL
+func fetch(conf *config) ([]byte, error) {
+	if conf.file != "" {
+		return ioutil.ReadFile(conf.file)
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: conf.insecure,
+	}
+	if conf.caCert != "" {
+		pem, err := ioutil.ReadFile(conf.caCert)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read --cacert: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in --cacert %s", conf.caCert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if conf.cert != "" {
+		cert, err := tls.LoadX509KeyPair(conf.cert, conf.key)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load --cert and --key: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	client := &http.Client{
+		Timeout: conf.timeout,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, conf.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", health.JSONContentType)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBody))
+	if err != nil {
+		return nil, err
+	}
+
+	// go-health Handlers return a document with non-200 statuses, so only
+	// complain about the status if there is no document
+	if (resp.StatusCode < 200 || resp.StatusCode > 299) && !json.Valid(body) {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return body, nil
+}
+
+// filter returns the items whose names are included and not excluded
+func filter(conf *config, items interface{}) []interface{} {
+	var filtered []interface{}
+	for _, item := range cast.ToSlice(items) {
+		name := cast.ToString(cast.ToStringMap(item)["name"])
+		if conf.include != nil && !conf.include.MatchString(name) {
+			continue
+		}
+		if conf.exclude != nil && conf.exclude.MatchString(name) {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	return filtered
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	health "github.com/cognusion/go-health"
+	nagios "github.com/cognusion/go-nagios-checks"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testServer() *httptest.Server {
+	return httptest.NewServer(health.NewHandler(func() health.Check {
+		now := time.Now()
+		hc := health.NewCheck()
+		hc.AddService(&health.Status{Name: "db", Status: health.OK, TimeStamp: &now})
+		hc.AddService(&health.Status{Name: "cache", Status: health.CRITICAL, TimeStamp: &now})
+		hc.AddSystem(&health.Status{Name: "disk", Status: health.WARNING, TimeStamp: &now})
+		hc.AddMetric(&health.Status{Name: "heap", Value: 12, WarnOver: 10, BadOver: 20})
+		return hc
+	}))
+}
+
+func Test_Run(t *testing.T) {
+
+	Convey("When check_gohealth is run against a URL", t, func() {
+		ts := testServer()
+		defer ts.Close()
+
+		Convey("everything is checked, and the worst status wins", func() {
+			n := run([]string{"--url", ts.URL}, ioutil.Discard)
+			So(n.Status(), ShouldEqual, nagios.CRITICAL)
+			So(n.Message, ShouldContainSubstring, "cache: CRITICAL")
+			So(n.Message, ShouldContainSubstring, "disk: WARNING")
+			So(n.Message, ShouldNotContainSubstring, "db: OK")
+			So(n.FullMessage(), ShouldContainSubstring, "| 'heap'=12;10;20;;")
+		})
+
+		Convey("noisy includes OK items", func() {
+			n := run([]string{"--url", ts.URL, "--noisy"}, ioutil.Discard)
+			So(n.Message, ShouldContainSubstring, "db: OK")
+		})
+
+		Convey("excluded items are skipped", func() {
+			n := run([]string{"--url", ts.URL, "--exclude", "^cache$"}, ioutil.Discard)
+			So(n.Status(), ShouldEqual, nagios.WARNING)
+			So(n.Message, ShouldNotContainSubstring, "cache")
+		})
+
+		Convey("only included items are checked", func() {
+			n := run([]string{"--url", ts.URL, "--include", "^db$"}, ioutil.Discard)
+			So(n.Status(), ShouldEqual, nagios.OK)
+			So(n.Message, ShouldEqual, "1 of 4 items matched filters, all OK")
+			So(n.Metrics, ShouldBeEmpty)
+		})
+
+		Convey("if nothing matches the filters, it is UNKNOWN", func() {
+			n := run([]string{"--url", ts.URL, "--include", "^nope$"}, ioutil.Discard)
+			So(n.Status(), ShouldEqual, nagios.UNKNOWN)
+			So(n.Message, ShouldEqual, "nothing matched filters, of 4 items")
+
+			n = run([]string{"--url", ts.URL, "--exclude", "."}, ioutil.Discard)
+			So(n.Status(), ShouldEqual, nagios.UNKNOWN)
+		})
+	})
+
+	Convey("When check_gohealth is run against a file", t, func() {
+		file := filepath.Join(t.TempDir(), "health.json")
+		So(ioutil.WriteFile(file, []byte(`{"overallStatus":"OK","services":[{"name":"db","status":"UP"}]}`), 0600), ShouldBeNil)
+
+		n := run([]string{"--file", file}, ioutil.Discard)
+		So(n.Status(), ShouldEqual, nagios.OK)
+	})
+
+	Convey("When check_gohealth is given something other than a go-health document, it is UNKNOWN", t, func() {
+		for _, doc := range []string{`{}`, `{"foo":"bar","status":"DOWN"}`} {
+			file := filepath.Join(t.TempDir(), "health.json")
+			So(ioutil.WriteFile(file, []byte(doc), 0600), ShouldBeNil)
+
+			n := run([]string{"--file", file}, ioutil.Discard)
+			So(n.Status(), ShouldEqual, nagios.UNKNOWN)
+			So(n.Message, ShouldEqual, "not a go-health document: no overallStatus")
+		}
+	})
+
+	Convey("When check_gohealth is given a document with no items, the overallStatus is used", t, func() {
+		file := filepath.Join(t.TempDir(), "health.json")
+		So(ioutil.WriteFile(file, []byte(`{"overallStatus":"CRITICAL"}`), 0600), ShouldBeNil)
+
+		n := run([]string{"--file", file}, ioutil.Discard)
+		So(n.Status(), ShouldEqual, nagios.CRITICAL)
+		So(n.Message, ShouldEqual, "overallStatus CRITICAL, with no items")
+	})
+
+	Convey("When check_gohealth cannot fetch the URL, it is CRITICAL", t, func() {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+		}))
+		defer ts.Close()
+
+		n := run([]string{"--url", ts.URL}, ioutil.Discard)
+		So(n.Status(), ShouldEqual, nagios.CRITICAL)
+		So(n.Message, ShouldContainSubstring, "502")
+
+		n = run([]string{"--url", ts.URL, "--timeout", "1ns"}, ioutil.Discard)
+		So(n.Status(), ShouldEqual, nagios.CRITICAL)
+	})
+
+	Convey("When check_gohealth is misconfigured, it is UNKNOWN", t, func() {
+		So(run([]string{}, ioutil.Discard).Status(), ShouldEqual, nagios.UNKNOWN)
+		So(run([]string{"--url", "x", "--file", "y"}, ioutil.Discard).Status(), ShouldEqual, nagios.UNKNOWN)
+		So(run([]string{"--url", "x", "--cert", "y"}, ioutil.Discard).Status(), ShouldEqual, nagios.UNKNOWN)
+		So(run([]string{"--url", "x", "--include", "("}, ioutil.Discard).Status(), ShouldEqual, nagios.UNKNOWN)
+		So(run([]string{"--bogus"}, ioutil.Discard).Status(), ShouldEqual, nagios.UNKNOWN)
+	})
+}
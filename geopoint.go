@@ -0,0 +1,90 @@
+package health
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/spf13/cast"
+)
+
+// earthRadius is the mean radius of the Earth, in kilometers
+const earthRadius = 6371.0088
+
+// GeoPoint is a Value or ExpectedValue representing a location, in decimal degrees.
+// A Metric with a GeoPoint Value and ExpectedValue is evaluated by the distance between
+// them, in kilometers, so WarnOver and BadOver are a radius around the ExpectedValue.
+type GeoPoint struct {
+	// Lat is the latitude, from -90 to 90
+	Lat float64 `json:"lat"`
+	// Lon is the longitude, from -180 to 180
+	Lon float64 `json:"lon"`
+}
+
+// ParseGeoPoint returns the GeoPoint represented by v, which may be a GeoPoint, *GeoPoint, or a
+// map with numeric "lat" and "lon" keys. The second return is false if v is not a valid GeoPoint.
+func ParseGeoPoint(v interface{}) (GeoPoint, bool) {
+	var p GeoPoint
+	switch v := v.(type) {
+	case GeoPoint:
+		p = v
+	case *GeoPoint:
+		if v == nil {
+			return p, false
+		}
+		p = *v
+	case map[string]interface{}:
+		m := lcKeys(v)
+		lat, latOK := isNumericGimme(cast.ToString(m["lat"]))
+		lon, lonOK := isNumericGimme(cast.ToString(m["lon"]))
+		if !latOK || !lonOK {
+			return p, false
+		}
+		p = GeoPoint{Lat: lat, Lon: lon}
+	default:
+		return p, false
+	}
+	return p, p.Valid()
+}
+
+// Valid returns true if the latitude and longitude are in range
+func (g GeoPoint) Valid() bool {
+	return g.Lat >= -90 && g.Lat <= 90 && g.Lon >= -180 && g.Lon <= 180
+}
+
+// DistanceTo returns the great-circle distance between the GeoPoints, in kilometers
+func (g GeoPoint) DistanceTo(o GeoPoint) float64 {
+	lat1 := g.Lat * math.Pi / 180
+	lat2 := o.Lat * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (o.Lon - g.Lon) * math.Pi / 180
+
+	a := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// String returns the GeoPoint as "lat,lon"
+func (g GeoPoint) String() string {
+	return strconv.FormatFloat(g.Lat, 'f', -1, 64) + "," + strconv.FormatFloat(g.Lon, 'f', -1, 64)
+}
+
+// geoValue returns v as a GeoPoint if it is one, or v otherwise
+func geoValue(v interface{}) interface{} {
+	if p, ok := ParseGeoPoint(v); ok {
+		return p
+	}
+	return v
+}
+
+// geoDistance returns the distance between the Value and ExpectedValue of the Status, in
+// kilometers. The second return is false if either is not a GeoPoint.
+func (s *Status) geoDistance() (float64, bool) {
+	p, ok := ParseGeoPoint(s.Value)
+	if !ok {
+		return 0, false
+	}
+	e, ok := ParseGeoPoint(s.ExpectedValue)
+	if !ok {
+		return 0, false
+	}
+	return p.DistanceTo(e), true
+}
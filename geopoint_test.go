@@ -0,0 +1,87 @@
+package health
+
+import (
+	nagios "github.com/cognusion/go-nagios-checks"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"testing"
+)
+
+func Test_GeoPoint(t *testing.T) {
+
+	Convey("When GeoPoints are parsed, only valid points are accepted", t, func() {
+		p, ok := ParseGeoPoint(map[string]interface{}{"Lat": 40.7128, "lon": -74.006})
+		So(ok, ShouldBeTrue)
+		So(p, ShouldResemble, GeoPoint{Lat: 40.7128, Lon: -74.006})
+		So(p.String(), ShouldEqual, "40.7128,-74.006")
+
+		_, ok = ParseGeoPoint(&p)
+		So(ok, ShouldBeTrue)
+
+		for _, v := range []interface{}{
+			nil,
+			12,
+			map[string]interface{}{"lat": 1},
+			map[string]interface{}{"lat": "north", "lon": 1},
+			map[string]interface{}{"lat": 91, "lon": 1},
+			GeoPoint{Lat: 1, Lon: 181},
+		} {
+			_, ok := ParseGeoPoint(v)
+			So(ok, ShouldBeFalse)
+		}
+	})
+
+	Convey("When the distance between GeoPoints is calculated, it is correct", t, func() {
+		nyc := GeoPoint{Lat: 40.7128, Lon: -74.006}
+		la := GeoPoint{Lat: 34.0522, Lon: -118.2437}
+		So(nyc.DistanceTo(la), ShouldAlmostEqual, 3936, 5)
+		So(la.DistanceTo(nyc), ShouldAlmostEqual, nyc.DistanceTo(la))
+		So(nyc.DistanceTo(nyc), ShouldEqual, 0)
+	})
+
+	Convey("When a Check has a GeoPoint metric, its distance from ExpectedValue drives the status", t, func() {
+		hc, err := NewCheckfromJSON([]byte(`{"overallStatus":"OK","metrics":[
+			{"name":"edge1","value":{"lat":40.7128,"lon":-74.006},"expectedValue":{"lat":40.73,"lon":-73.99},"warnOver":1,"badOver":10}
+		]}`))
+		So(err, ShouldBeNil)
+		So(hc.Metrics[0].Value, ShouldResemble, GeoPoint{Lat: 40.7128, Lon: -74.006})
+		So(hc.OverallStatus, ShouldEqual, WARNING)
+		So(hc.Metrics[0].MetricString(), ShouldEqual, "'edge1'=2.340;1;10;;")
+		So(hc.Validate(), ShouldBeNil)
+		So(hc.JSON(), ShouldContainSubstring, `"value":{"lat":40.7128,"lon":-74.006}`)
+
+		hc.Metrics[0].Value = GeoPoint{Lat: 34.0522, Lon: -118.2437}
+		hc.Calculate()
+		So(hc.OverallStatus, ShouldEqual, CRITICAL)
+
+		Convey("and without an ExpectedValue, it does not contribute", func() {
+			hc.Metrics[0].ExpectedValue = nil
+			hc.Calculate()
+			So(hc.OverallStatus, ShouldEqual, OK)
+			So(hc.Metrics[0].MetricString(), ShouldEqual, "'edge1'=U;1;10;;")
+		})
+
+		Convey("and an invalid GeoPoint does not validate", func() {
+			So(ValidateJSON(`{"overallStatus":"OK","metrics":[{"name":"edge1","value":{"lat":100,"lon":0}}]}`), ShouldNotBeNil)
+		})
+	})
+
+	Convey("When a metrics document has a GeoPoint metric, Metrics renders it", t, func() {
+		metrics := []interface{}{
+			map[string]interface{}{
+				"name":          "edge1",
+				"value":         map[string]interface{}{"lat": 34.0522, "lon": -118.2437},
+				"expectedValue": map[string]interface{}{"lat": 40.7128, "lon": -74.006},
+				"badOver":       100,
+			},
+		}
+
+		var n nagios.Nagios
+		Metrics(&n, metrics, false)
+		So(n.Status(), ShouldEqual, nagios.CRITICAL)
+		So(n.Message, ShouldContainSubstring, " CRITICAL edge1=34.0522,-118.2437 (3935.")
+		So(n.Message, ShouldContainSubstring, "km from 40.7128,-74.006)")
+		So(len(n.Metrics), ShouldEqual, 1)
+		So(n.Metrics[0], ShouldStartWith, "'edge1'=3935.")
+	})
+}
@@ -0,0 +1,139 @@
+package health
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Content types understood by Handler
+const (
+	// JSONContentType is the content type of the full Check JSON
+	JSONContentType = "application/json"
+	// TerseContentType may be requested via the Accept header to receive Check.Terse() output
+	TerseContentType = "application/vnd.go-health.terse+json"
+)
+
+// DefaultCacheControl is the Cache-Control header value used by a Handler unless otherwise specified
+const DefaultCacheControl = "no-cache, no-store, must-revalidate"
+
+// DefaultStatusCodes returns a new map of OverallStatus values to the HTTP status codes
+// a Handler will respond with. OK and WARNING are 200, everything else is 503.
+func DefaultStatusCodes() map[Severity]int {
+	return map[Severity]int{
+		OK:       http.StatusOK,
+		UP:       http.StatusOK,
+		WARNING:  http.StatusOK,
+		UNKNOWN:  http.StatusServiceUnavailable,
+		BAD:      http.StatusServiceUnavailable,
+		ERROR:    http.StatusServiceUnavailable,
+		DOWN:     http.StatusServiceUnavailable,
+		CRITICAL: http.StatusServiceUnavailable,
+	}
+}
+
+// Handler is an http.Handler that serves a Check, mapping its OverallStatus to an
+// HTTP status code. The full Check is served unless "terse" is requested, either via
+// the "terse" query parameter, or an Accept header of TerseContentType. If the Accept
+// header includes HealthJSONContentType, the Check is served as Check.HealthJSON().
+type Handler struct {
+	// CheckFunc is called for every request, and must return a Check. The Check is
+	// Calculate()d before it is served
+	CheckFunc func() Check
+	// StatusCodes maps OverallStatus values to HTTP status codes
+	StatusCodes map[Severity]int
+	// DefaultStatusCode is used when OverallStatus is not in StatusCodes
+	DefaultStatusCode int
+	// CacheControl is the value of the Cache-Control header. If empty, no header is sent
+	CacheControl string
+}
+
+// NewHandler returns a Handler that serves the Check returned by the provided function,
+// with the DefaultStatusCodes and DefaultCacheControl
+func NewHandler(f func() Check) *Handler {
+	return &Handler{
+		CheckFunc:         f,
+		StatusCodes:       DefaultStatusCodes(),
+		DefaultStatusCode: http.StatusServiceUnavailable,
+		CacheControl:      DefaultCacheControl,
+	}
+}
+
+// NewRegistryHandler returns a Handler that serves the contents of the provided StatusRegistry
+func NewRegistryHandler(sr *StatusRegistry) *Handler {
+	return NewHandler(sr.Snapshot)
+}
+
+// ServeHTTP handles GET and HEAD requests, responding with the Check
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	hc := h.CheckFunc()
+	hc.Calculate()
+
+	var body, contentType string
+	switch {
+	case wantsTerse(r):
+		body = hc.Terse()
+		contentType = JSONContentType
+	case accepts(r, HealthJSONContentType):
+		body = hc.HealthJSON()
+		contentType = HealthJSONContentType
+	default:
+		body = hc.JSON()
+		contentType = JSONContentType
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if h.CacheControl != "" {
+		w.Header().Set("Cache-Control", h.CacheControl)
+	}
+	w.WriteHeader(h.statusCode(hc.OverallStatus))
+
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Write([]byte(body))
+}
+
+// statusCode returns the HTTP status code for the provided OverallStatus
+func (h *Handler) statusCode(status Severity) int {
+	if code, ok := h.StatusCodes[status]; ok {
+		return code
+	}
+	if h.DefaultStatusCode != 0 {
+		return h.DefaultStatusCode
+	}
+	return http.StatusServiceUnavailable
+}
+
+// wantsTerse returns true if the request asks for terse output
+func wantsTerse(r *http.Request) bool {
+	q := r.URL.Query()
+	if _, ok := q["terse"]; ok {
+		t := q.Get("terse")
+		if t == "" {
+			return true
+		}
+		b, err := strconv.ParseBool(t)
+		return err == nil && b
+	}
+	return accepts(r, TerseContentType)
+}
+
+// accepts returns true if the request's Accept header includes the provided media type
+func accepts(r *http.Request, mediaType string) bool {
+	for _, a := range r.Header.Values("Accept") {
+		for _, mt := range strings.Split(a, ",") {
+			if mt, _, _ = strings.Cut(strings.TrimSpace(mt), ";"); strings.EqualFold(mt, mediaType) {
+				return true
+			}
+		}
+	}
+	return false
+}
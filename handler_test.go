@@ -0,0 +1,122 @@
+package health
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_Handler(t *testing.T) {
+
+	Convey("When a Handler serves a healthy Check", t, func() {
+		h := NewHandler(func() Check {
+			hc := NewCheck()
+			hc.AddService(&Status{Name: "db", Status: OK})
+			return hc
+		})
+
+		Convey("a GET returns the full JSON with a 200", func() {
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			So(rr.Code, ShouldEqual, http.StatusOK)
+			So(rr.Header().Get("Content-Type"), ShouldEqual, JSONContentType)
+			So(rr.Header().Get("Cache-Control"), ShouldEqual, DefaultCacheControl)
+
+			hc, err := NewCheckfromJSON(rr.Body.Bytes())
+			So(err, ShouldBeNil)
+			So(hc.OverallStatus, ShouldEqual, OK)
+			So(len(hc.Services), ShouldEqual, 1)
+		})
+
+		Convey("a GET with ?terse returns the terse JSON", func() {
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health?terse", nil))
+
+			So(rr.Code, ShouldEqual, http.StatusOK)
+			So(rr.Body.String(), ShouldEqual, `{"overallStatus":"OK"}`)
+		})
+
+		Convey("a GET with ?terse=false returns the full JSON", func() {
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health?terse=false", nil))
+
+			So(rr.Body.String(), ShouldNotEqual, `{"overallStatus":"OK"}`)
+		})
+
+		Convey("a GET accepting the terse content type returns the terse JSON", func() {
+			req := httptest.NewRequest(http.MethodGet, "/health", nil)
+			req.Header.Set("Accept", "text/html, "+TerseContentType+";q=0.9")
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			So(rr.Body.String(), ShouldEqual, `{"overallStatus":"OK"}`)
+		})
+
+		Convey("a HEAD returns headers and no body", func() {
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodHead, "/health", nil))
+
+			So(rr.Code, ShouldEqual, http.StatusOK)
+			So(rr.Header().Get("Content-Length"), ShouldNotEqual, "")
+			So(rr.Body.Len(), ShouldEqual, 0)
+		})
+
+		Convey("a POST is not allowed", func() {
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/health", nil))
+
+			So(rr.Code, ShouldEqual, http.StatusMethodNotAllowed)
+			So(rr.Header().Get("Allow"), ShouldEqual, "GET, HEAD")
+		})
+	})
+
+	Convey("When a Handler serves a critical Check", t, func() {
+		h := NewHandler(func() Check {
+			hc := NewCheck()
+			hc.AddService(&Status{Name: "db", Status: CRITICAL})
+			return hc
+		})
+
+		Convey("the default status code is 503", func() {
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+			So(rr.Code, ShouldEqual, http.StatusServiceUnavailable)
+		})
+
+		Convey("a custom mapping is honored", func() {
+			h.StatusCodes[CRITICAL] = http.StatusInternalServerError
+			h.CacheControl = ""
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+			So(rr.Code, ShouldEqual, http.StatusInternalServerError)
+			So(rr.Header().Get("Cache-Control"), ShouldEqual, "")
+		})
+	})
+
+	Convey("When a Handler serves a StatusRegistry over a real server", t, func() {
+		sr := NewStatusRegistry()
+		sr.Add("db", WARNING, nil, nil)
+
+		ts := httptest.NewServer(NewRegistryHandler(sr))
+		defer ts.Close()
+
+		resp, err := http.Get(ts.URL)
+		So(err, ShouldBeNil)
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		So(err, ShouldBeNil)
+
+		So(resp.StatusCode, ShouldEqual, http.StatusOK)
+
+		var v map[string]interface{}
+		So(json.Unmarshal(body, &v), ShouldBeNil)
+		So(v["overallStatus"], ShouldEqual, string(WARNING))
+	})
+}
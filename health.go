@@ -8,6 +8,7 @@ import (
 
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 // JSON is an encapsulating type for "jmap"-based structures
@@ -16,17 +17,35 @@ type JSON map[string]interface{}
 // Check is a type used to define healthcheck statuses
 type Check struct {
 	// OverallStatus must be one of OK,WARNING,BAD/ERROR/CRITICAL, or UNKNOWN
-	OverallStatus string                 `json:"overallStatus,omitempty"`
+	OverallStatus Severity               `json:"overallStatus,omitempty"`
 	Services      []Status               `json:"services,omitempty"`
 	Systems       []Status               `json:"systems,omitempty"`
 	Metrics       []Status               `json:"metrics,omitempty"`
 	Properties    map[string]interface{} `json:"properties,omitempty"`
+	// MaxAge is the amount of time a Status TimeStamp may drift before the Status is
+	// considered stale, when the Status has no TimeOut of its own. If 0, DefaultMaxAge is used
+	MaxAge time.Duration `json:"-"`
+	// StaleStatus is the status stale entries escalate OverallStatus to, one of WARNING,
+	// CRITICAL, or UNKNOWN. If empty, DefaultStaleStatus is used
+	StaleStatus Severity `json:"-"`
+	// Policy determines OverallStatus when Calculate()d. If nil, DefaultPolicy is used
+	Policy StatusPolicy `json:"-"`
 }
 
+var (
+	// DefaultMaxAge is the amount of time a Status TimeStamp may drift before the Status is
+	// considered stale, when neither the Status has a TimeOut nor the Check has a MaxAge.
+	// If 0, only Statuses with a TimeOut can be stale.
+	DefaultMaxAge time.Duration
+	// DefaultStaleStatus is the status stale entries escalate OverallStatus to, when the
+	// Check has no StaleStatus
+	DefaultStaleStatus = WARNING
+)
+
 // NewCheck returns an empty Check
 func NewCheck() Check {
 	return Check{
-		OverallStatus: "UNKNOWN",
+		OverallStatus: UNKNOWN,
 		Properties:    make(map[string]interface{}),
 	}
 }
@@ -43,7 +62,7 @@ func NewCheckfromJSON(hcjson []byte) (Check, error) {
 
 	hc.Services = StatusSliceFromJmap(cast.ToSlice(jmap["services"]))
 	hc.Systems = StatusSliceFromJmap(cast.ToSlice(jmap["systems"]))
-	hc.Metrics = StatusSliceFromJmap(cast.ToSlice(jmap["metrics"]))
+	hc.Metrics = StatusSliceFromJmap(FlattenMetrics(cast.ToSlice(jmap["metrics"]), DefaultMaxMetricDepth))
 	if props, ok := jmap["properties"]; ok {
 		hc.Properties = cast.ToStringMap(props)
 	}
@@ -116,98 +135,33 @@ func (s *Check) AddMetric(status *Status) {
 	s.Metrics = append(s.Metrics, *status)
 }
 
-// Calculate walks the tree and updates OverallStatus if applicable
+// Calculate walks the tree and updates OverallStatus according to the Policy, or the
+// DefaultPolicy if there is none. Every Status with a TimeStamp older than its TimeOut
+// (or MaxAge) is marked Stale first, and escalates to StaleStatus.
 func (s *Check) Calculate() {
-	ostatus := OK
-
-FLOOP:
-	for _, service := range s.Services {
-		switch service.Status {
-		case OK:
-		case UP:
-		case WARNING:
-			if ostatus == OK || ostatus == UNKNOWN {
-				ostatus = WARNING
-			}
-		case BAD:
-			fallthrough
-		case ERROR:
-			fallthrough
-		case DOWN:
-			fallthrough
-		case CRITICAL:
-			ostatus = CRITICAL
-			break FLOOP
-		case UNKNOWN:
-			if ostatus != CRITICAL && ostatus != WARNING {
-				ostatus = UNKNOWN
-			}
-		}
+	s.markStale(time.Now())
+
+	policy := s.Policy
+	if policy == nil {
+		policy = DefaultPolicy{}
 	}
+	s.OverallStatus = policy.OverallStatus(s)
+}
 
-	if ostatus != CRITICAL {
-	NCFLOOP:
-		for _, system := range s.Systems {
-			switch system.Status {
-			case OK:
-			case UP:
-			case WARNING:
-				if ostatus == OK || ostatus == UNKNOWN {
-					ostatus = WARNING
-				}
-			case BAD:
-				fallthrough
-			case ERROR:
-				fallthrough
-			case DOWN:
-				fallthrough
-			case CRITICAL:
-				ostatus = CRITICAL
-				break NCFLOOP
-			case UNKNOWN:
-				if ostatus != CRITICAL && ostatus != WARNING {
-					ostatus = UNKNOWN
-				}
-			}
-		}
+// markStale updates Stale on every Status whose staleness can be determined
+func (s *Check) markStale(now time.Time) {
+	maxAge := s.MaxAge
+	if maxAge == 0 {
+		maxAge = DefaultMaxAge
 	}
 
-	if ostatus != CRITICAL {
-	MFLOOP:
-		for _, metric := range s.Metrics {
-			//fmt.Printf("Metric %s = %v\n", metric.Name, metric.Value)
-			if metric.Status != "" {
-				//fmt.Printf("\thas status '%s'\n", metric.Status)
-				switch metric.Status {
-				case OK:
-				case UP:
-				case WARNING:
-					if ostatus == OK || ostatus == UNKNOWN {
-						ostatus = WARNING
-					}
-				case BAD:
-					fallthrough
-				case ERROR:
-					fallthrough
-				case DOWN:
-					fallthrough
-				case CRITICAL:
-					ostatus = CRITICAL
-					break MFLOOP
-				}
-			} else if v, ok := isNumericGimme(cast.ToString(metric.Value)); ok {
-				// No status declared, but value is a number, so lets see what we got with the other numbers
-				//fmt.Printf("\tis %+v\n", metric)
-				if cv, ok := isNumericGimme(cast.ToString(metric.BadOver)); ok && v > cv {
-					ostatus = CRITICAL
-				} else if wv, ok := isNumericGimme(cast.ToString(metric.WarnOver)); ok && v > wv {
-					ostatus = WARNING
-				}
+	for _, statuses := range [][]Status{s.Services, s.Systems, s.Metrics} {
+		for i := range statuses {
+			if stale, ok := statuses[i].isStale(now, maxAge); ok {
+				statuses[i].Stale = stale
 			}
 		}
 	}
-
-	s.OverallStatus = ostatus
 }
 
 // JSON returns the JSON-encoded version of the Check
@@ -222,7 +176,7 @@ func (s *Check) JSON() string {
 // Terse returns the JSON-encoded version of just the overall status of the Check
 func (s *Check) Terse() string {
 	ts := make(map[string]string)
-	ts["overallStatus"] = s.OverallStatus
+	ts["overallStatus"] = string(s.OverallStatus)
 
 	j, err := json.Marshal(&ts)
 	if err != nil {
@@ -1,12 +1,14 @@
 package health
 
 import (
+	nagios "github.com/cognusion/go-nagios-checks"
 	. "github.com/smartystreets/goconvey/convey"
 	jschema "github.com/xeipuuv/gojsonschema"
 
 	"encoding/json"
 	"io/ioutil"
 	"testing"
+	"time"
 )
 
 var apiJSON = []byte(`{
@@ -403,6 +405,20 @@ func Test_NewCheckfromJSON(t *testing.T) {
 	})
 }
 
+func Test_StatusMessages(t *testing.T) {
+
+	Convey("When a Status has a Message and Error, they round-trip through JSON", t, func() {
+		hc := NewCheck()
+		hc.AddService(&Status{Name: "db", Status: DOWN, Message: "failing over", Error: "connection refused"})
+		So(hc.Validate(), ShouldBeNil)
+
+		hc2, err := NewCheckfromJSON([]byte(hc.JSON()))
+		So(err, ShouldBeNil)
+		So(hc2.Services[0].Message, ShouldEqual, "failing over")
+		So(hc2.Services[0].Error, ShouldEqual, "connection refused")
+	})
+}
+
 func Test_StatusMetric(t *testing.T) {
 
 	Convey("When an Check has a metric that is critical, the resulting status is correct", t, func() {
@@ -414,12 +430,60 @@ func Test_StatusMetric(t *testing.T) {
 		So(hc, ShouldNotEqual, Check{})
 
 		Convey("The resulting status and MetricString are correct", func() {
-			So(hc.OverallStatus, ShouldEqual, "CRITICAL")
+			So(hc.OverallStatus, ShouldEqual, CRITICAL)
 			So(hc.Metrics[len(hc.Metrics)-1].MetricString(), ShouldEqual, "'broken.test.value'=6;0;5;;")
 		})
 	})
 }
 
+func Test_MetricStringPerfdata(t *testing.T) {
+
+	Convey("When a metric has MinValue, MaxValue, and a Suffix, MetricString is complete perfdata", t, func() {
+		st := Status{Name: "heap", Value: 512, Suffix: "MB", WarnOver: 768, BadOver: 900, MinValue: 0, MaxValue: 1024}
+		So(st.MetricString(), ShouldEqual, "'heap'=512MB;768;900;0;1024")
+
+		Convey("and invalid units of measure are left out", func() {
+			st.Suffix = "megs"
+			So(st.MetricString(), ShouldEqual, "'heap'=512;768;900;0;1024")
+		})
+	})
+
+	Convey("When MinValue, MaxValue, and Suffix are in JSON, they round-trip", t, func() {
+		hc, err := NewCheckfromJSON([]byte(`{"overallStatus":"OK","metrics":[
+			{"name":"latency","value":12.5,"suffix":"ms","minValue":0,"maxValue":1000}
+		]}`))
+		So(err, ShouldBeNil)
+		So(hc.Validate(), ShouldBeNil)
+		So(hc.Metrics[0].MetricString(), ShouldEqual, "'latency'=12.5ms;;;0;1000")
+		So(hc.JSON(), ShouldContainSubstring, `"minValue":0,"maxValue":1000,"suffix":"ms"`)
+	})
+
+	Convey("When a metrics document has a Suffix, Metrics includes valid units of measure", t, func() {
+		var n nagios.Nagios
+		Metrics(&n, []interface{}{
+			map[string]interface{}{"name": "load", "value": 50, "suffix": "%", "maxValue": 100},
+			map[string]interface{}{"name": "temp", "value": 40, "suffix": "celsius"},
+		}, false)
+		So(n.Metrics, ShouldResemble, []string{"'load'=50%;;;;100", "'temp'=40;;;;"})
+	})
+}
+
+func Test_NestedMetrics(t *testing.T) {
+
+	Convey("When NewCheckfromJSON is called with nested metric maps, they are flattened into child metrics", t, func() {
+		hc, err := NewCheckfromJSON([]byte(`{"overallStatus":"OK","metrics":[
+			{"name":"memory","badOver":100,"suffix":"MB","value":{"heap":123,"nonheap":45}}
+		]}`))
+		So(err, ShouldBeNil)
+		So(len(hc.Metrics), ShouldEqual, 2)
+		So(hc.Metrics[0].Name, ShouldEqual, "memory.heap")
+		So(hc.Metrics[0].BadOver, ShouldEqual, 100)
+		So(hc.Metrics[1].MetricString(), ShouldEqual, "'memory.nonheap'=45MB;;100;;")
+		So(hc.OverallStatus, ShouldEqual, CRITICAL)
+		So(hc.Validate(), ShouldBeNil)
+	})
+}
+
 func Test_Merge(t *testing.T) {
 
 	Convey("When NewCheckfromJSON is called on known good JSON, the result is a valid Check", t, func() {
@@ -553,3 +617,73 @@ func Test_JSONOutput(t *testing.T) {
 
 	})
 }
+
+func Test_CalculateStale(t *testing.T) {
+
+	Convey("When a Check has a Status with a TimeStamp older than its TimeOut", t, func() {
+		old := time.Now().Add(-time.Hour)
+		fresh := time.Now()
+		to := time.Minute
+
+		hc := NewCheck()
+		hc.AddService(&Status{Name: "fresh", Status: OK, TimeStamp: &fresh, TimeOut: &to})
+		hc.AddService(&Status{Name: "old", Status: OK, TimeStamp: &old, TimeOut: &to})
+		hc.AddSystem(&Status{Name: "untimed", Status: OK, TimeStamp: &old})
+
+		Convey("it is marked Stale, and escalates to WARNING by default", func() {
+			hc.Calculate()
+			So(hc.OverallStatus, ShouldEqual, WARNING)
+			So(hc.Services[0].Stale, ShouldBeFalse)
+			So(hc.Services[1].Stale, ShouldBeTrue)
+			So(hc.Systems[0].Stale, ShouldBeFalse)
+			So(hc.JSON(), ShouldContainSubstring, `"name":"old","status":"OK","timestamp":`)
+			So(hc.JSON(), ShouldContainSubstring, `"stale":true`)
+			So(hc.Validate(), ShouldBeNil)
+		})
+
+		Convey("it escalates to the StaleStatus", func() {
+			hc.StaleStatus = CRITICAL
+			hc.Calculate()
+			So(hc.OverallStatus, ShouldEqual, CRITICAL)
+
+			hc.StaleStatus = UNKNOWN
+			hc.Calculate()
+			So(hc.OverallStatus, ShouldEqual, UNKNOWN)
+		})
+
+		Convey("it does not de-escalate a worse status", func() {
+			hc.AddService(&Status{Name: "down", Status: DOWN})
+			hc.Calculate()
+			So(hc.OverallStatus, ShouldEqual, CRITICAL)
+		})
+
+		Convey("MaxAge applies to Statuses without a TimeOut", func() {
+			hc.MaxAge = 30 * time.Minute
+			hc.Calculate()
+			So(hc.Systems[0].Stale, ShouldBeTrue)
+		})
+
+		Convey("once refreshed, it is no longer Stale", func() {
+			hc.Calculate()
+			hc.Services[1].TimeStamp = &fresh
+			hc.Calculate()
+			So(hc.OverallStatus, ShouldEqual, OK)
+			So(hc.Services[1].Stale, ShouldBeFalse)
+		})
+	})
+
+	Convey("When Stale and TimeOut round-trip through JSON, they are preserved", t, func() {
+		old := time.Now().Add(-time.Hour)
+		to := 2 * time.Minute
+
+		hc := NewCheck()
+		hc.AddService(&Status{Name: "old", Status: OK, TimeStamp: &old, TimeOut: &to})
+		hc.Calculate()
+
+		hc2, err := NewCheckfromJSON([]byte(hc.JSON()))
+		So(err, ShouldBeNil)
+		So(*hc2.Services[0].TimeOut, ShouldEqual, to)
+		So(hc2.Services[0].Stale, ShouldBeTrue)
+		So(hc2.OverallStatus, ShouldEqual, WARNING)
+	})
+}
@@ -0,0 +1,272 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// HealthJSONContentType is the content type of the IETF draft-inadarei-api-health-check format
+const HealthJSONContentType = "application/health+json"
+
+// IETF health check statuses
+const (
+	healthJSONPass = "pass"
+	healthJSONWarn = "warn"
+	healthJSONFail = "fail"
+)
+
+// healthJSONUnknown begins the output of anything UNKNOWN, which the draft has no status for
+const healthJSONUnknown = "UNKNOWN"
+
+// componentTypes used when encoding, and honored when decoding
+const (
+	healthJSONComponent = "component"
+	healthJSONSystem    = "system"
+	healthJSONMetric    = "metric"
+)
+
+// healthJSONProperties are the top-level fields that are carried in Check.Properties
+var healthJSONProperties = []string{"version", "releaseId", "notes", "output", "serviceId", "description", "links"}
+
+// healthJSONCheck is a single entry in the "checks" object
+type healthJSONCheck struct {
+	ComponentID   string      `json:"componentId,omitempty"`
+	ComponentType string      `json:"componentType,omitempty"`
+	ObservedValue interface{} `json:"observedValue,omitempty"`
+	ObservedUnit  string      `json:"observedUnit,omitempty"`
+	Status        string      `json:"status,omitempty"`
+	Time          string      `json:"time,omitempty"`
+	Output        string      `json:"output,omitempty"`
+}
+
+// HealthJSON returns the IETF "application/health+json" encoded version of the Check.
+//
+// Services, Systems, and Metrics are keyed by name in "checks", with a componentType of
+// "component", "system", or "metric" respectively. Value becomes observedValue, Suffix
+// becomes observedUnit, TimeStamp becomes time, and Error (or Message, if there is no
+// Error) becomes output. The top-level version, releaseId, notes,
+// output, serviceId, description, and links fields are taken from Properties.
+//
+// OK and its aliases become "pass", WARNING becomes "warn", and CRITICAL and its aliases become
+// "fail". The draft has no status for UNKNOWN, so it becomes "warn", with an output of
+// "UNKNOWN", or "UNKNOWN: <output>", so it can be told apart from a degraded "warn".
+func (s *Check) HealthJSON() string {
+	doc := make(map[string]interface{})
+	doc["status"] = toHealthJSONStatus(s.OverallStatus)
+
+	for _, k := range healthJSONProperties {
+		if v, ok := s.Properties[k]; ok {
+			doc[k] = v
+		}
+	}
+	if s.OverallStatus.Canonical() == UNKNOWN {
+		doc["output"] = toHealthJSONOutput(s.OverallStatus, cast.ToString(doc["output"]))
+	}
+
+	checks := make(map[string][]healthJSONCheck)
+	for _, sect := range []struct {
+		componentType string
+		statuses      []Status
+	}{
+		{healthJSONComponent, s.Services},
+		{healthJSONSystem, s.Systems},
+		{healthJSONMetric, s.Metrics},
+	} {
+		for _, st := range sect.statuses {
+			c := healthJSONCheck{
+				ComponentType: sect.componentType,
+				ObservedValue: st.Value,
+				ObservedUnit:  st.Suffix,
+				Output:        st.Error,
+			}
+			if c.Output == "" {
+				c.Output = st.Message
+			}
+			if st.Status != "" {
+				c.Status = toHealthJSONStatus(st.Status)
+				c.Output = toHealthJSONOutput(st.Status, c.Output)
+			}
+			if st.TimeStamp != nil {
+				c.Time = st.TimeStamp.Format(time.RFC3339Nano)
+			}
+			checks[st.Name] = append(checks[st.Name], c)
+		}
+	}
+	if len(checks) > 0 {
+		doc["checks"] = checks
+	}
+
+	j, err := json.Marshal(doc)
+	if err != nil {
+		return "{}"
+	}
+	return string(j)
+}
+
+// NewCheckfromHealthJSON returns a Check populated from an IETF "application/health+json" document.
+//
+// Each entry in "checks" is named by its key. Entries with a componentType of "system" become
+// Systems, "metric" become Metrics, and "component" become Services. Entries with other
+// componentTypes become Metrics if their observedValue is numeric, and Services otherwise.
+// Statuses of pass, warn, and fail map to OK, WARNING, and CRITICAL respectively, and any
+// other status to UNKNOWN, as does a warn whose output is "UNKNOWN" or begins "UNKNOWN: ", as
+// HealthJSON encodes it. The output of an entry, less any such prefix, becomes its Message if it
+// passes, and its Error otherwise. A malformed entry does not fail the document, but becomes an UNKNOWN
+// Service with the reason as its Error.
+func NewCheckfromHealthJSON(hcjson []byte) (Check, error) {
+	var hc = NewCheck()
+
+	jmap, err := jsonToMap(hcjson)
+	if err != nil {
+		return hc, err
+	}
+
+	for _, k := range healthJSONProperties {
+		if v, ok := jmap[k]; ok {
+			hc.Properties[k] = v
+		}
+	}
+
+	var doc struct {
+		Status string                     `json:"status"`
+		Checks map[string]json.RawMessage `json:"checks"`
+	}
+	if err := json.Unmarshal(hcjson, &doc); err != nil {
+		return hc, err
+	}
+
+	for _, name := range sortedCheckKeys(doc.Checks) {
+		var entries []json.RawMessage
+		if err := json.Unmarshal(doc.Checks[name], &entries); err != nil {
+			hc.AddService(malformedHealthJSONCheck(name, err))
+			continue
+		}
+
+		for _, entry := range entries {
+			var c healthJSONCheck
+			if err := json.Unmarshal(entry, &c); err != nil {
+				hc.AddService(malformedHealthJSONCheck(name, err))
+				continue
+			}
+
+			st := Status{
+				Name:   name,
+				Value:  c.ObservedValue,
+				Suffix: c.ObservedUnit,
+			}
+			if c.Status != "" {
+				st.Status, c.Output = fromHealthJSONStatusOutput(c.Status, c.Output)
+			}
+			if st.Status.IsOK() {
+				st.Message = c.Output
+			} else {
+				st.Error = c.Output
+			}
+			if c.Time != "" {
+				if ts, err := time.Parse(time.RFC3339Nano, c.Time); err == nil {
+					st.TimeStamp = &ts
+				}
+			}
+
+			switch c.ComponentType {
+			case healthJSONSystem:
+				hc.AddSystem(&st)
+			case healthJSONMetric:
+				hc.AddMetric(&st)
+			case healthJSONComponent:
+				hc.AddService(&st)
+			default:
+				if _, ok := isNumericGimme(cast.ToString(c.ObservedValue)); ok {
+					hc.AddMetric(&st)
+				} else {
+					hc.AddService(&st)
+				}
+			}
+		}
+	}
+
+	if len(doc.Checks) == 0 {
+		// Nothing to calculate from, so trust the document
+		hc.OverallStatus, _ = fromHealthJSONStatusOutput(doc.Status, cast.ToString(jmap["output"]))
+		return hc, nil
+	}
+
+	hc.Calculate()
+	return hc, nil
+}
+
+// malformedHealthJSONCheck returns an UNKNOWN Status for the named entry, which could not be decoded
+func malformedHealthJSONCheck(name string, err error) *Status {
+	return &Status{
+		Name:   name,
+		Status: UNKNOWN,
+		Error:  fmt.Sprintf("malformed check: %v", err),
+	}
+}
+
+// sortedCheckKeys returns the keys of the map, sorted
+func sortedCheckKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toHealthJSONStatus maps a status onto pass, warn, or fail
+func toHealthJSONStatus(status Severity) string {
+	switch status.Canonical() {
+	case OK:
+		return healthJSONPass
+	case CRITICAL:
+		return healthJSONFail
+	}
+	return healthJSONWarn
+}
+
+// toHealthJSONOutput returns the output, prefixed with "UNKNOWN" if the status is UNKNOWN
+func toHealthJSONOutput(status Severity, output string) string {
+	if status.Canonical() != UNKNOWN {
+		return output
+	}
+	if output == "" {
+		return healthJSONUnknown
+	}
+	return healthJSONUnknown + ": " + output
+}
+
+// fromHealthJSONStatusOutput maps the status onto the Status constants, as fromHealthJSONStatus
+// does, unless it is a warn with an output marking it UNKNOWN, as toHealthJSONOutput makes. The
+// output is returned without the mark.
+func fromHealthJSONStatusOutput(status, output string) (Severity, string) {
+	sev := fromHealthJSONStatus(status)
+	if sev != WARNING {
+		return sev, output
+	}
+	if output == healthJSONUnknown {
+		return UNKNOWN, ""
+	}
+	if strings.HasPrefix(output, healthJSONUnknown+": ") {
+		return UNKNOWN, strings.TrimPrefix(output, healthJSONUnknown+": ")
+	}
+	return sev, output
+}
+
+// fromHealthJSONStatus maps pass, warn, or fail (and their common aliases) onto the Status constants
+func fromHealthJSONStatus(status string) Severity {
+	switch strings.ToLower(status) {
+	case healthJSONPass, "ok", "up":
+		return OK
+	case healthJSONWarn:
+		return WARNING
+	case healthJSONFail, "error", "down":
+		return CRITICAL
+	}
+	return UNKNOWN
+}
@@ -0,0 +1,259 @@
+package health
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// ietfJSON is adapted from the example in draft-inadarei-api-health-check
+var ietfJSON = []byte(`{
+  "status": "warn",
+  "version": "1",
+  "releaseId": "1.2.2",
+  "notes": [""],
+  "output": "",
+  "serviceId": "f03e522f-1f44-4062-9b55-9587f91c9c41",
+  "description": "health of authz service",
+  "checks": {
+    "cassandra:responseTime": [
+      {
+        "componentId": "dfd6cf2b-1b6e-4412-a0b8-f6f7797a60d2",
+        "componentType": "datastore",
+        "observedValue": 250,
+        "observedUnit": "ms",
+        "status": "pass",
+        "affectedEndpoints": [
+          "/users/{userId}",
+          "/customers/{customerId}/status",
+          "/shopping/{anything}"
+        ],
+        "time": "2018-01-17T03:36:48Z",
+        "output": ""
+      }
+    ],
+    "cassandra:connections": [
+      {
+        "componentId": "dfd6cf2b-1b6e-4412-a0b8-f6f7797a60d2",
+        "componentType": "datastore",
+        "observedValue": 75,
+        "status": "warn",
+        "time": "2018-01-17T03:36:48Z",
+        "output": "",
+        "links": {
+          "self": "http://api.example.com/dbnode/dfd6cf2b/health"
+        }
+      }
+    ],
+    "uptime": [
+      {
+        "componentType": "system",
+        "observedValue": 1209600.245,
+        "observedUnit": "s",
+        "status": "pass",
+        "time": "2018-01-17T03:36:48Z"
+      }
+    ],
+    "cpu:utilization": [
+      {
+        "componentId": "6fd416e0-8920-410f-9c7b-c479000f7227",
+        "node": 1,
+        "componentType": "system",
+        "observedValue": 85,
+        "observedUnit": "percent",
+        "status": "warn",
+        "time": "2018-01-17T03:36:48Z",
+        "output": ""
+      }
+    ],
+    "queue": [
+      {
+        "componentType": "component",
+        "status": "pass"
+      }
+    ]
+  },
+  "links": {
+    "about": "http://api.example.com/about/authz",
+    "http://api.x.io/rel/thresholds": "http://api.x.io/about/authz/thresholds"
+  }
+}`)
+
+func Test_NewCheckfromHealthJSON(t *testing.T) {
+
+	Convey("When NewCheckfromHealthJSON is called on the IETF example document", t, func() {
+		hc, err := NewCheckfromHealthJSON(ietfJSON)
+		So(err, ShouldBeNil)
+
+		Convey("the statuses are mapped, and the checks are sorted into sections", func() {
+			So(hc.OverallStatus, ShouldEqual, WARNING)
+
+			So(len(hc.Services), ShouldEqual, 1)
+			So(hc.Services[0].Name, ShouldEqual, "queue")
+			So(hc.Services[0].Status, ShouldEqual, OK)
+
+			So(len(hc.Systems), ShouldEqual, 2)
+			So(hc.Systems[0].Name, ShouldEqual, "cpu:utilization")
+			So(hc.Systems[0].Status, ShouldEqual, WARNING)
+			So(hc.Systems[0].Suffix, ShouldEqual, "percent")
+
+			So(len(hc.Metrics), ShouldEqual, 2)
+			So(hc.Metrics[1].Name, ShouldEqual, "cassandra:responseTime")
+			So(hc.Metrics[1].Value, ShouldEqual, 250)
+			So(hc.Metrics[1].Suffix, ShouldEqual, "ms")
+			So(hc.Metrics[1].TimeStamp.Equal(time.Date(2018, 1, 17, 3, 36, 48, 0, time.UTC)), ShouldBeTrue)
+		})
+
+		Convey("the top-level fields are Properties", func() {
+			So(hc.Properties["releaseId"], ShouldEqual, "1.2.2")
+			So(hc.Properties["links"], ShouldNotBeNil)
+		})
+
+		Convey("it round-trips through HealthJSON", func() {
+			hc2, err := NewCheckfromHealthJSON([]byte(hc.HealthJSON()))
+			So(err, ShouldBeNil)
+			So(hc2, ShouldResemble, hc)
+		})
+	})
+
+	Convey("When a Check with a Message and Error is encoded with HealthJSON, they become output", t, func() {
+		hc := NewCheck()
+		hc.AddService(&Status{Name: "db", Status: DOWN, Message: "failing over", Error: "connection refused"})
+		hc.AddService(&Status{Name: "cache", Status: UP, Message: "warm"})
+		hc.Calculate()
+
+		hj := hc.HealthJSON()
+		So(hj, ShouldContainSubstring, `"output":"connection refused"`)
+		So(hj, ShouldContainSubstring, `"output":"warm"`)
+
+		hc2, err := NewCheckfromHealthJSON([]byte(hj))
+		So(err, ShouldBeNil)
+		So(hc2.Services[0].Name, ShouldEqual, "cache")
+		So(hc2.Services[0].Message, ShouldEqual, "warm")
+		So(hc2.Services[1].Error, ShouldEqual, "connection refused")
+	})
+
+	Convey("When a Check is encoded with HealthJSON", t, func() {
+		ts := time.Date(2022, 4, 17, 12, 0, 0, 0, time.UTC)
+		hc := NewCheck()
+		hc.Properties["version"] = "2"
+		hc.Properties["ignored"] = true
+		hc.AddService(&Status{Name: "db", Status: DOWN, TimeStamp: &ts})
+		hc.AddSystem(&Status{Name: "disk", Status: UP})
+		hc.AddMetric(&Status{Name: "heap", Value: 1024, Suffix: "KB"})
+		hc.Calculate()
+
+		var doc map[string]interface{}
+		So(json.Unmarshal([]byte(hc.HealthJSON()), &doc), ShouldBeNil)
+
+		Convey("the document is in the IETF format", func() {
+			So(doc["status"], ShouldEqual, "fail")
+			So(doc["version"], ShouldEqual, "2")
+			So(doc, ShouldNotContainKey, "ignored")
+
+			checks := doc["checks"].(map[string]interface{})
+			db := checks["db"].([]interface{})[0].(map[string]interface{})
+			So(db["status"], ShouldEqual, "fail")
+			So(db["componentType"], ShouldEqual, "component")
+			So(db["time"], ShouldEqual, "2022-04-17T12:00:00Z")
+
+			heap := checks["heap"].([]interface{})[0].(map[string]interface{})
+			So(heap, ShouldNotContainKey, "status")
+			So(heap["observedValue"], ShouldEqual, 1024)
+			So(heap["observedUnit"], ShouldEqual, "KB")
+		})
+
+		Convey("it round-trips through NewCheckfromHealthJSON", func() {
+			hc2, err := NewCheckfromHealthJSON([]byte(hc.HealthJSON()))
+			So(err, ShouldBeNil)
+			So(hc2.OverallStatus, ShouldEqual, CRITICAL)
+			So(hc2.Services[0].Status, ShouldEqual, CRITICAL)
+			So(hc2.Services[0].TimeStamp.Equal(ts), ShouldBeTrue)
+			So(hc2.Systems[0].Status, ShouldEqual, OK)
+			So(hc2.Metrics[0].Value, ShouldEqual, 1024)
+			So(hc2.Metrics[0].Suffix, ShouldEqual, "KB")
+		})
+	})
+
+	Convey("When a Check with an UNKNOWN status is encoded with HealthJSON, it is a warn with the detail in output", t, func() {
+		hc := NewCheck()
+		hc.AddService(&Status{Name: "queue", Status: UNKNOWN, Error: "no response"})
+		hc.AddService(&Status{Name: "cache", Status: WARNING, Error: "evicting"})
+		hc.AddService(&Status{Name: "db", Status: UNKNOWN})
+		hc.Calculate()
+		hc.OverallStatus = UNKNOWN
+
+		var doc struct {
+			Status string                       `json:"status"`
+			Output string                       `json:"output"`
+			Checks map[string][]healthJSONCheck `json:"checks"`
+		}
+		hj := hc.HealthJSON()
+		So(json.Unmarshal([]byte(hj), &doc), ShouldBeNil)
+		So(doc.Status, ShouldEqual, "warn")
+		So(doc.Output, ShouldEqual, "UNKNOWN")
+		So(doc.Checks["queue"][0].Status, ShouldEqual, "warn")
+		So(doc.Checks["queue"][0].Output, ShouldEqual, "UNKNOWN: no response")
+		So(doc.Checks["cache"][0].Status, ShouldEqual, "warn")
+		So(doc.Checks["cache"][0].Output, ShouldEqual, "evicting")
+		So(doc.Checks["db"][0].Output, ShouldEqual, "UNKNOWN")
+
+		hc2, err := NewCheckfromHealthJSON([]byte(hj))
+		So(err, ShouldBeNil)
+		So(hc2.Services[0].Status, ShouldEqual, WARNING)
+		So(hc2.Services[0].Error, ShouldEqual, "evicting")
+		So(hc2.Services[1].Status, ShouldEqual, UNKNOWN)
+		So(hc2.Services[1].Error, ShouldBeEmpty)
+		So(hc2.Services[2].Status, ShouldEqual, UNKNOWN)
+		So(hc2.Services[2].Error, ShouldEqual, "no response")
+
+		hc3, err := NewCheckfromHealthJSON([]byte(`{"status":"warn","output":"UNKNOWN"}`))
+		So(err, ShouldBeNil)
+		So(hc3.OverallStatus, ShouldEqual, UNKNOWN)
+	})
+
+	Convey("When NewCheckfromHealthJSON is called with malformed entries, they are reported as UNKNOWN", t, func() {
+		hc, err := NewCheckfromHealthJSON([]byte(`{"status":"pass","checks":{
+			"db": [{"componentType":"component","status":"pass"}, {"status":42}],
+			"disk": {"status":"pass"}
+		}}`))
+		So(err, ShouldBeNil)
+		So(len(hc.Services), ShouldEqual, 3)
+		So(hc.Services[0].Name, ShouldEqual, "db")
+		So(hc.Services[0].Status, ShouldEqual, OK)
+		So(hc.Services[1].Status, ShouldEqual, UNKNOWN)
+		So(hc.Services[1].Error, ShouldStartWith, "malformed check: ")
+		So(hc.Services[2].Name, ShouldEqual, "disk")
+		So(hc.Services[2].Status, ShouldEqual, UNKNOWN)
+	})
+
+	Convey("When NewCheckfromHealthJSON is called on a document without checks, the status is used", t, func() {
+		hc, err := NewCheckfromHealthJSON([]byte(`{"status":"fail"}`))
+		So(err, ShouldBeNil)
+		So(hc.OverallStatus, ShouldEqual, CRITICAL)
+	})
+
+	Convey("When a Handler is asked for application/health+json, it serves it", t, func() {
+		h := NewHandler(func() Check {
+			hc := NewCheck()
+			hc.AddService(&Status{Name: "db", Status: CRITICAL})
+			return hc
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.Header.Set("Accept", HealthJSONContentType)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		So(rr.Code, ShouldEqual, http.StatusServiceUnavailable)
+		So(rr.Header().Get("Content-Type"), ShouldEqual, HealthJSONContentType)
+
+		hc, err := NewCheckfromHealthJSON(rr.Body.Bytes())
+		So(err, ShouldBeNil)
+		So(hc.OverallStatus, ShouldEqual, CRITICAL)
+	})
+}
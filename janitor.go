@@ -0,0 +1,149 @@
+package health
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultJanitorInterval is how often a Janitor Expires entries, if no interval is specified
+const DefaultJanitorInterval = 10 * time.Second
+
+// ExpiryAction is what happens to StatusRegistry entries when their TTL expires
+type ExpiryAction int
+
+// ExpiryAction constants
+const (
+	// ExpireUnknown marks expired entries Stale and UNKNOWN, until they are Heartbeat or replaced
+	ExpireUnknown ExpiryAction = iota
+	// ExpireStale marks expired entries Stale, so Check.Calculate escalates them to its StaleStatus
+	ExpireStale
+	// ExpireRemove removes expired entries
+	ExpireRemove
+)
+
+// AddWithTTL adds or updates an entry in StatusRegistry as Add does, expiring after ttl
+// unless it is Heartbeat or replaced. The TTL is the TimeOut of the Status.
+func (s *StatusRegistry) AddWithTTL(name string, status Severity, Value, ExpectedValue interface{}, ttl time.Duration) {
+	sev, _ := ParseSeverity(string(status))
+	now := time.Now()
+	s.set("", name, Status{
+		Status:        sev,
+		Value:         Value,
+		ExpectedValue: ExpectedValue,
+		TimeStamp:     &now,
+		TimeOut:       &ttl,
+	})
+}
+
+// SetTTL sets the TTL of an existing entry in StatusRegistry, or returns ErrNoSuchEntryError.
+// If the entry has no TimeStamp, it is set to now. A ttl of 0 means the entry never expires.
+func (s *StatusRegistry) SetTTL(name string, ttl time.Duration) error {
+	return s.update(name, func(e *registryEntry, now time.Time) {
+		e.status.TimeOut = &ttl
+		if e.status.TimeStamp == nil {
+			e.status.TimeStamp = &now
+		}
+	})
+}
+
+// Heartbeat refreshes the TimeStamp of an existing entry in StatusRegistry, or returns
+// ErrNoSuchEntryError. An entry that has expired is restored to its status before it expired.
+func (s *StatusRegistry) Heartbeat(name string) error {
+	return s.update(name, func(e *registryEntry, now time.Time) {
+		e.status.TimeStamp = &now
+		e.status.Stale = false
+		if e.expired {
+			e.status.Status = e.unexpired
+			e.expired = false
+		}
+	})
+}
+
+// Expire applies the action to every entry in StatusRegistry whose TTL has expired, and returns
+// the number of entries affected. Entries already marked by an earlier Expire are not counted.
+func (s *StatusRegistry) Expire(action ExpiryAction) int {
+	return s.expire(action, time.Now())
+}
+
+// expire applies the action to every entry that has expired as of now
+func (s *StatusRegistry) expire(action ExpiryAction, now time.Time) int {
+	s.Lock()
+	defer s.Unlock()
+
+	var n int
+	for _, k := range s.keys() {
+		e := s.stats[k]
+		if stale, ok := e.status.isStale(now, 0); !ok || !stale {
+			continue
+		}
+
+		old := e.status
+		switch action {
+		case ExpireRemove:
+			delete(s.stats, k)
+			s.notify(k, &old, nil)
+			n++
+			continue
+		case ExpireUnknown:
+			if !e.expired {
+				e.expired = true
+				e.unexpired = e.status.Status
+				e.status.Status = UNKNOWN
+			}
+		}
+		if e.status.Stale && old.Status == e.status.Status {
+			// Already marked
+			continue
+		}
+		e.status.Stale = true
+		s.stats[k] = e
+		s.notify(k, &old, copyStatus(e.status))
+		n++
+	}
+	return n
+}
+
+// Janitor periodically Expires entries in a StatusRegistry
+type Janitor struct {
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// StartJanitor starts a Janitor that Expires entries in StatusRegistry with the action,
+// every interval, until it is Stopped. If interval is not positive, DefaultJanitorInterval is used.
+func (s *StatusRegistry) StartJanitor(interval time.Duration, action ExpiryAction) *Janitor {
+	if interval <= 0 {
+		interval = DefaultJanitorInterval
+	}
+
+	j := &Janitor{
+		stop: make(chan struct{}),
+	}
+
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-j.stop:
+				return
+			case now := <-t.C:
+				s.expire(action, now)
+			}
+		}
+	}()
+	return j
+}
+
+// Stop the Janitor, waiting for any in-progress Expire to complete. Stop may be called
+// more than once.
+func (j *Janitor) Stop() {
+	j.once.Do(func() {
+		close(j.stop)
+	})
+	j.wg.Wait()
+}
@@ -0,0 +1,85 @@
+package health
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+
+	"testing"
+	"time"
+)
+
+func Test_Expiry(t *testing.T) {
+
+	Convey("When StatusRegistry entries have TTLs, they expire", t, func() {
+		sr := NewStatusRegistry()
+		sr.AddWithTTL("worker", OK, nil, nil, time.Minute)
+		sr.Add("forever", OK, nil, nil)
+		later := time.Now().Add(2 * time.Minute)
+
+		So(sr.expire(ExpireUnknown, time.Now()), ShouldEqual, 0)
+
+		Convey("ExpireUnknown marks them stale and UNKNOWN, until they are Heartbeat", func() {
+			So(sr.expire(ExpireUnknown, later), ShouldEqual, 1)
+			So(sr.expire(ExpireUnknown, later), ShouldEqual, 0)
+
+			stat, err := sr.Get("worker")
+			So(err, ShouldBeNil)
+			So(stat.Status, ShouldEqual, UNKNOWN)
+			So(stat.Stale, ShouldBeTrue)
+			So(sr.Snapshot().OverallStatus, ShouldEqual, UNKNOWN)
+
+			So(sr.Heartbeat("worker"), ShouldBeNil)
+			stat, err = sr.Get("worker")
+			So(err, ShouldBeNil)
+			So(stat.Status, ShouldEqual, OK)
+			So(stat.Stale, ShouldBeFalse)
+			So(sr.Snapshot().OverallStatus, ShouldEqual, OK)
+		})
+
+		Convey("ExpireStale marks them stale, which Calculate escalates", func() {
+			So(sr.expire(ExpireStale, later), ShouldEqual, 1)
+
+			stat, err := sr.Get("worker")
+			So(err, ShouldBeNil)
+			So(stat.Status, ShouldEqual, OK)
+			So(stat.Stale, ShouldBeTrue)
+		})
+
+		Convey("ExpireRemove removes them", func() {
+			So(sr.expire(ExpireRemove, later), ShouldEqual, 1)
+			So(sr.Keys(), ShouldResemble, []string{"forever"})
+		})
+
+		Convey("SetTTL gives an entry a TTL, and Heartbeat refreshes it", func() {
+			So(sr.SetTTL("forever", time.Minute), ShouldBeNil)
+			So(sr.expire(ExpireRemove, later), ShouldEqual, 2)
+
+			So(sr.SetTTL("nope", time.Minute), ShouldEqual, ErrNoSuchEntryError)
+			So(sr.Heartbeat("nope"), ShouldEqual, ErrNoSuchEntryError)
+		})
+	})
+
+	Convey("When a Janitor is started, expired entries are expired until it is Stopped", t, func() {
+		sr := NewStatusRegistry()
+		sub := sr.Watch(WatchFilter{}, 10)
+		defer sub.Close()
+
+		sr.AddWithTTL("worker", OK, nil, nil, 10*time.Millisecond)
+		j := sr.StartJanitor(5*time.Millisecond, ExpireRemove)
+		defer j.Stop()
+
+		var e StatusEvent
+		for i := 0; i < 2; i++ {
+			select {
+			case e = <-sub.C:
+			case <-time.After(time.Second):
+				So("timed out", ShouldBeEmpty)
+			}
+		}
+		So(e.Name, ShouldEqual, "worker")
+		So(e.New, ShouldBeNil)
+		So(sr.Keys(), ShouldBeEmpty)
+
+		j.Stop()
+		j.Stop()
+	})
+}
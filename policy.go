@@ -0,0 +1,225 @@
+package health
+
+import (
+	"strings"
+)
+
+// StatusPolicy determines the OverallStatus of a Check. Implementations should use
+// Check.StatusOf to determine the status of each Service, System, and Metric.
+type StatusPolicy interface {
+	// OverallStatus returns one of OK, WARNING, CRITICAL, or UNKNOWN for the Check
+	OverallStatus(c *Check) Severity
+}
+
+// PolicyFunc is a function that is a StatusPolicy
+type PolicyFunc func(c *Check) Severity
+
+// OverallStatus calls the function
+func (f PolicyFunc) OverallStatus(c *Check) Severity {
+	return f(c)
+}
+
+// DefaultPolicy is the StatusPolicy used when a Check has none. The worst status of any
+// Service, System, or Metric wins, in the order CRITICAL, WARNING, UNKNOWN, then OK. As
+// Calculate always has, Metrics that declare themselves UNKNOWN are ignored, unless stale.
+type DefaultPolicy struct{}
+
+// OverallStatus returns the worst status in the Check, ignoring UNKNOWN Metrics
+func (DefaultPolicy) OverallStatus(c *Check) Severity {
+	return Worst(
+		worstStatus(c, "", c.Services, c.Systems),
+		worstMetricStatus(c),
+	)
+}
+
+// WorstStatusPolicy is a StatusPolicy that is the DefaultPolicy, except that Metrics that
+// declare themselves UNKNOWN make the OverallStatus at least UNKNOWN
+type WorstStatusPolicy struct{}
+
+// OverallStatus returns the worst status in the Check
+func (WorstStatusPolicy) OverallStatus(c *Check) Severity {
+	return worstStatus(c, "", c.Services, c.Systems, c.Metrics)
+}
+
+// IgnoreMetricsPolicy is a StatusPolicy that is the DefaultPolicy, without considering Metrics
+type IgnoreMetricsPolicy struct{}
+
+// OverallStatus returns the worst status of the Services and Systems in the Check
+func (IgnoreMetricsPolicy) OverallStatus(c *Check) Severity {
+	return worstStatus(c, "", c.Services, c.Systems)
+}
+
+// SystemsDegradePolicy is a StatusPolicy that is the DefaultPolicy, except that Systems
+// can only degrade the OverallStatus to WARNING
+type SystemsDegradePolicy struct{}
+
+// OverallStatus returns the worst status in the Check, with Systems capped at WARNING
+func (SystemsDegradePolicy) OverallStatus(c *Check) Severity {
+	return Worst(
+		worstStatus(c, "", c.Services),
+		worstMetricStatus(c),
+		worstStatus(c, WARNING, c.Systems),
+	)
+}
+
+// QuorumPolicy is a StatusPolicy for replicated Services or Systems, all named with the same
+// Prefix. The replicas are only CRITICAL when at least MinDown of them are, and are otherwise
+// at worst WARNING. Everything else is as in the DefaultPolicy.
+type QuorumPolicy struct {
+	// Prefix is the beginning of the Name of every replica
+	Prefix string
+	// MinDown is the number of replicas that must be CRITICAL for the OverallStatus to be
+	// CRITICAL. If less than 1, 1 is used
+	MinDown int
+}
+
+// OverallStatus returns the worst status in the Check, with the replicas capped at WARNING
+// if fewer than MinDown are CRITICAL
+func (p QuorumPolicy) OverallStatus(c *Check) Severity {
+	var (
+		replicas []Status
+		others   []Status
+		down     int
+	)
+	for _, statuses := range [][]Status{c.Services, c.Systems} {
+		for i := range statuses {
+			if !strings.HasPrefix(statuses[i].Name, p.Prefix) {
+				others = append(others, statuses[i])
+				continue
+			}
+			replicas = append(replicas, statuses[i])
+			if c.StatusOf(&statuses[i]) == CRITICAL {
+				down++
+			}
+		}
+	}
+
+	var limit Severity
+	if down < p.MinDown {
+		limit = WARNING
+	}
+
+	return Worst(
+		worstStatus(c, "", others),
+		worstMetricStatus(c),
+		worstStatus(c, limit, replicas),
+	)
+}
+
+// WeightedPolicy is a StatusPolicy that scores the Check. Each CRITICAL Service, System,
+// or Metric adds its weight to the score, and each WARNING or UNKNOWN adds half of its weight.
+// The OverallStatus is CRITICAL if the score reaches Critical, WARNING if it reaches Warning,
+// and OK otherwise.
+type WeightedPolicy struct {
+	// Weights maps Names to their weight
+	Weights map[string]float64
+	// DefaultWeight is the weight of anything not in Weights
+	DefaultWeight float64
+	// Warning is the score at which the OverallStatus is WARNING
+	Warning float64
+	// Critical is the score at which the OverallStatus is CRITICAL
+	Critical float64
+}
+
+// OverallStatus returns the status of the Check according to its score
+func (p WeightedPolicy) OverallStatus(c *Check) Severity {
+	score := p.Score(c)
+	switch {
+	case p.Critical > 0 && score >= p.Critical:
+		return CRITICAL
+	case p.Warning > 0 && score >= p.Warning:
+		return WARNING
+	}
+	return OK
+}
+
+// Score returns the weighted score of the Check
+func (p WeightedPolicy) Score(c *Check) float64 {
+	var score float64
+	for _, statuses := range [][]Status{c.Services, c.Systems, c.Metrics} {
+		for i := range statuses {
+			weight, ok := p.Weights[statuses[i].Name]
+			if !ok {
+				weight = p.DefaultWeight
+			}
+
+			switch c.StatusOf(&statuses[i]) {
+			case CRITICAL:
+				score += weight
+			case WARNING, UNKNOWN:
+				score += weight / 2
+			}
+		}
+	}
+	return score
+}
+
+// StatusOf returns the status of the provided Service, System, or Metric in the context of
+// this Check, as one of OK, WARNING, CRITICAL, or UNKNOWN. Unrecognized statuses are UNKNOWN.
+// Metrics without a declared status are evaluated against their thresholds, by distance from
+// ExpectedValue for GeoPoints. Stale entries are escalated to the StaleStatus. The result is
+// capped according to the Importance of the entry. An empty Severity is returned if the status
+// cannot be determined, or the entry is informational.
+func (s *Check) StatusOf(st *Status) Severity {
+	var status Severity
+
+	switch st.Status {
+	case "":
+		// No status declared, so lets see what we got with the thresholds
+		status = st.valueStatus()
+	default:
+		status = st.Status.Canonical()
+	}
+
+	if st.Stale {
+		staleStatus := s.StaleStatus
+		if staleStatus == "" {
+			staleStatus = DefaultStaleStatus
+		}
+		status = Worst(status, staleStatus.Canonical())
+	}
+
+	limit := st.importanceLimit()
+	if limit == "" {
+		return ""
+	}
+	if status.WorseThan(limit) {
+		status = limit
+	}
+	return status
+}
+
+// worstStatus returns the worst status of all of the provided Statuses, capped at limit
+// if limit is not empty. If there are no Statuses, OK is returned.
+func worstStatus(c *Check, limit Severity, statuses ...[]Status) Severity {
+	worst := OK
+	for _, sts := range statuses {
+		for i := range sts {
+			worst = Worst(worst, c.StatusOf(&sts[i]))
+		}
+	}
+
+	if limit != "" && worst.WorseThan(limit) {
+		return limit
+	}
+	return worst
+}
+
+// worstMetricStatus returns the worst status of the Metrics in the Check as the DefaultPolicy
+// sees them, ignoring those that declare themselves UNKNOWN unless they are stale. If there
+// are no Metrics, OK is returned.
+func worstMetricStatus(c *Check) Severity {
+	worst := OK
+	for i := range c.Metrics {
+		m := c.Metrics[i]
+		if m.Status != "" && m.Status.Canonical() == UNKNOWN {
+			if !m.Stale {
+				continue
+			}
+			// Only its staleness counts
+			m.Status = OK
+		}
+		worst = Worst(worst, c.StatusOf(&m))
+	}
+	return worst
+}
@@ -0,0 +1,314 @@
+package health
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/spf13/cast"
+
+	"testing"
+	"time"
+)
+
+// baselineOverallStatus is the OverallStatus as Calculate determined it before StatusPolicies,
+// for a Check whose staleness has already been marked
+func baselineOverallStatus(s *Check) Severity {
+	ostatus := OK
+
+FLOOP:
+	for _, service := range s.Services {
+		switch service.Status {
+		case OK:
+		case UP:
+		case WARNING:
+			if ostatus == OK || ostatus == UNKNOWN {
+				ostatus = WARNING
+			}
+		case BAD, ERROR, DOWN, CRITICAL:
+			ostatus = CRITICAL
+			break FLOOP
+		case UNKNOWN:
+			if ostatus != CRITICAL && ostatus != WARNING {
+				ostatus = UNKNOWN
+			}
+		}
+	}
+
+	if ostatus != CRITICAL {
+	NCFLOOP:
+		for _, system := range s.Systems {
+			switch system.Status {
+			case OK:
+			case UP:
+			case WARNING:
+				if ostatus == OK || ostatus == UNKNOWN {
+					ostatus = WARNING
+				}
+			case BAD, ERROR, DOWN, CRITICAL:
+				ostatus = CRITICAL
+				break NCFLOOP
+			case UNKNOWN:
+				if ostatus != CRITICAL && ostatus != WARNING {
+					ostatus = UNKNOWN
+				}
+			}
+		}
+	}
+
+	if ostatus != CRITICAL {
+	MFLOOP:
+		for _, metric := range s.Metrics {
+			if metric.Status != "" {
+				switch metric.Status {
+				case OK:
+				case UP:
+				case WARNING:
+					if ostatus == OK || ostatus == UNKNOWN {
+						ostatus = WARNING
+					}
+				case BAD, ERROR, DOWN, CRITICAL:
+					ostatus = CRITICAL
+					break MFLOOP
+				}
+			} else if v, ok := isNumericGimme(cast.ToString(metric.Value)); ok {
+				switch thresholdStatus(v, metric.WarnOver, metric.BadOver, metric.WarnUnder, metric.BadUnder) {
+				case CRITICAL:
+					ostatus = CRITICAL
+					break MFLOOP
+				case WARNING:
+					if ostatus == OK || ostatus == UNKNOWN {
+						ostatus = WARNING
+					}
+				}
+			}
+		}
+	}
+
+	var stale bool
+	for _, statuses := range [][]Status{s.Services, s.Systems, s.Metrics} {
+		for i := range statuses {
+			stale = stale || statuses[i].Stale
+		}
+	}
+	if stale {
+		staleStatus := s.StaleStatus
+		if staleStatus == "" {
+			staleStatus = DefaultStaleStatus
+		}
+		ostatus = Worst(ostatus, staleStatus.Canonical())
+	}
+	return ostatus
+}
+
+func policyCheck() Check {
+	hc := NewCheck()
+	hc.AddService(&Status{Name: "db", Status: UP})
+	hc.AddService(&Status{Name: "web-1", Status: DOWN})
+	hc.AddService(&Status{Name: "web-2", Status: UP})
+	hc.AddService(&Status{Name: "web-3", Status: UP})
+	hc.AddSystem(&Status{Name: "disk", Status: CRITICAL})
+	hc.AddMetric(&Status{Name: "heap", Value: 95, WarnOver: 80, BadOver: 90})
+	return hc
+}
+
+func Test_Policies(t *testing.T) {
+
+	Convey("When a Check is Calculated with the DefaultPolicy, the worst status wins", t, func() {
+		hc := policyCheck()
+		hc.Calculate()
+		So(hc.OverallStatus, ShouldEqual, CRITICAL)
+
+		hc = NewCheck()
+		hc.AddService(&Status{Name: "a", Status: UNKNOWN})
+		hc.AddService(&Status{Name: "b", Status: OK})
+		hc.Calculate()
+		So(hc.OverallStatus, ShouldEqual, UNKNOWN)
+
+		hc.AddMetric(&Status{Name: "c", Value: 5, WarnOver: 1})
+		hc.Calculate()
+		So(hc.OverallStatus, ShouldEqual, WARNING)
+	})
+
+	Convey("When the baseline fixtures are Calculated with the DefaultPolicy, the results are unchanged", t, func() {
+		old := time.Now().Add(-time.Hour)
+		minute := time.Minute
+		var fixtures []Check
+		for _, j := range [][]byte{apiJSON, workerJSON, actuatorJSON, ietfJSON} {
+			hc, err := NewCheckfromJSON(j)
+			So(err, ShouldBeNil)
+			fixtures = append(fixtures, hc)
+		}
+
+		for _, statuses := range [][]Status{
+			{{Name: "m", Status: UNKNOWN}},
+			{{Name: "m", Status: UNKNOWN}, {Name: "n", Value: 5, WarnOver: 1}},
+			{{Name: "m", Status: UNKNOWN, TimeStamp: &old, TimeOut: &minute}},
+			{{Name: "m", Value: 5, BadOver: 1}, {Name: "n", Value: 5, WarnOver: 1}},
+			{{Name: "m", Value: 5, WarnUnder: 10}, {Name: "n", Status: UP}},
+			{{Name: "m", Value: 5, BadOver: "@0:10"}},
+			{{Name: "m", Value: "five", BadOver: 1}},
+			{{Name: "m", Status: BAD}},
+		} {
+			for _, service := range []Severity{OK, UNKNOWN, WARNING, DOWN} {
+				hc := NewCheck()
+				hc.AddService(&Status{Name: "s", Status: service})
+				for i := range statuses {
+					m := statuses[i]
+					hc.AddMetric(&m)
+				}
+				fixtures = append(fixtures, hc)
+			}
+		}
+
+		hc := NewCheck()
+		hc.AddSystem(&Status{Name: "s", Status: UNKNOWN})
+		hc.AddSystem(&Status{Name: "t", Status: OK, TimeStamp: &old, TimeOut: &minute})
+		fixtures = append(fixtures, hc)
+		hc = NewCheck()
+		hc.StaleStatus = UNKNOWN
+		hc.AddService(&Status{Name: "s", Status: OK, TimeStamp: &old, TimeOut: &minute})
+		fixtures = append(fixtures, hc)
+
+		for i := range fixtures {
+			fixtures[i].Calculate()
+			So(fixtures[i].OverallStatus, ShouldEqual, baselineOverallStatus(&fixtures[i]))
+		}
+	})
+
+	Convey("When a Check is Calculated with the WorstStatusPolicy, UNKNOWN metrics count", t, func() {
+		hc := NewCheck()
+		hc.AddService(&Status{Name: "db", Status: UP})
+		hc.AddMetric(&Status{Name: "heap", Status: UNKNOWN})
+		hc.Calculate()
+		So(hc.OverallStatus, ShouldEqual, OK)
+
+		hc.Policy = WorstStatusPolicy{}
+		hc.Calculate()
+		So(hc.OverallStatus, ShouldEqual, UNKNOWN)
+	})
+
+	Convey("When a Check is Calculated with the IgnoreMetricsPolicy, metrics are ignored", t, func() {
+		hc := NewCheck()
+		hc.Policy = IgnoreMetricsPolicy{}
+		hc.AddService(&Status{Name: "db", Status: UP})
+		hc.AddMetric(&Status{Name: "heap", Value: 95, BadOver: 90})
+		hc.Calculate()
+		So(hc.OverallStatus, ShouldEqual, OK)
+	})
+
+	Convey("When a Check is Calculated with the SystemsDegradePolicy, systems cap at WARNING", t, func() {
+		hc := NewCheck()
+		hc.Policy = SystemsDegradePolicy{}
+		hc.AddService(&Status{Name: "db", Status: UP})
+		hc.AddSystem(&Status{Name: "disk", Status: CRITICAL})
+		hc.Calculate()
+		So(hc.OverallStatus, ShouldEqual, WARNING)
+
+		hc.AddService(&Status{Name: "cache", Status: DOWN})
+		hc.Calculate()
+		So(hc.OverallStatus, ShouldEqual, CRITICAL)
+	})
+
+	Convey("When a Check is Calculated with a QuorumPolicy", t, func() {
+		hc := NewCheck()
+		hc.Policy = QuorumPolicy{Prefix: "web-", MinDown: 2}
+		hc.AddService(&Status{Name: "db", Status: UP})
+		hc.AddService(&Status{Name: "web-1", Status: DOWN})
+		hc.AddService(&Status{Name: "web-2", Status: UP})
+		hc.AddService(&Status{Name: "web-3", Status: UP})
+
+		Convey("fewer than MinDown replicas down is WARNING", func() {
+			hc.Calculate()
+			So(hc.OverallStatus, ShouldEqual, WARNING)
+		})
+
+		Convey("MinDown replicas down is CRITICAL", func() {
+			hc.Services[2].Status = CRITICAL
+			hc.Calculate()
+			So(hc.OverallStatus, ShouldEqual, CRITICAL)
+		})
+
+		Convey("non-replicas are not subject to the quorum", func() {
+			hc.Services[0].Status = DOWN
+			hc.Calculate()
+			So(hc.OverallStatus, ShouldEqual, CRITICAL)
+		})
+	})
+
+	Convey("When a Check is Calculated with a WeightedPolicy, the score determines the status", t, func() {
+		hc := policyCheck()
+		p := WeightedPolicy{
+			Weights:       map[string]float64{"disk": 0.5, "heap": 0},
+			DefaultWeight: 1,
+			Warning:       1,
+			Critical:      2,
+		}
+		hc.Policy = p
+
+		So(p.Score(&hc), ShouldEqual, 1.5)
+		hc.Calculate()
+		So(hc.OverallStatus, ShouldEqual, WARNING)
+
+		hc.Services[0].Status = WARNING
+		hc.Calculate()
+		So(hc.OverallStatus, ShouldEqual, CRITICAL)
+	})
+
+	Convey("When a Check is Calculated with a PolicyFunc, it is called", t, func() {
+		hc := policyCheck()
+		hc.Policy = PolicyFunc(func(c *Check) Severity {
+			return c.StatusOf(&c.Services[0])
+		})
+		hc.Calculate()
+		So(hc.OverallStatus, ShouldEqual, OK)
+	})
+
+	Convey("When a Policy is set, Merge honors it", t, func() {
+		hc := NewCheck()
+		hc.Policy = SystemsDegradePolicy{}
+		other := policyCheck()
+		hc.Merge(&other)
+		So(hc.OverallStatus, ShouldEqual, CRITICAL)
+
+		other.Services[1].Status = UP
+		hc = NewCheck()
+		hc.Policy = SystemsDegradePolicy{}
+		hc.Merge(&other)
+		So(hc.OverallStatus, ShouldEqual, WARNING)
+	})
+}
+
+func Test_Importance(t *testing.T) {
+
+	Convey("When a Check has Statuses with an Importance", t, func() {
+		hc := NewCheck()
+		hc.AddService(&Status{Name: "db", Status: UP, Importance: ImportanceRequired})
+		hc.AddService(&Status{Name: "recommendations", Status: DOWN, Importance: ImportanceDegraded})
+		hc.AddSystem(&Status{Name: "build", Status: CRITICAL, Importance: ImportanceInformational})
+		hc.AddMetric(&Status{Name: "trivia", Value: 100, BadOver: 10, Importance: ImportanceInformational})
+
+		Convey("optional failures cap at WARNING, and informational ones are ignored", func() {
+			hc.Calculate()
+			So(hc.OverallStatus, ShouldEqual, WARNING)
+			So(hc.StatusOf(&hc.Services[1]), ShouldEqual, WARNING)
+			So(hc.StatusOf(&hc.Systems[0]), ShouldBeEmpty)
+		})
+
+		Convey("required failures are still CRITICAL", func() {
+			hc.Services[0].Status = DOWN
+			hc.Calculate()
+			So(hc.OverallStatus, ShouldEqual, CRITICAL)
+		})
+
+		Convey("the Importance round-trips through JSON and validates", func() {
+			So(hc.Validate(), ShouldBeNil)
+			hc2, err := NewCheckfromJSON([]byte(hc.JSON()))
+			So(err, ShouldBeNil)
+			So(hc2.Services[1].Importance, ShouldEqual, ImportanceDegraded)
+			So(hc2.OverallStatus, ShouldEqual, WARNING)
+		})
+
+		Convey("an invalid Importance does not validate", func() {
+			hc.Services[0].Importance = "whenever"
+			So(hc.Validate(), ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,211 @@
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// Default thresholds for CertProbes, in days until expiry
+const (
+	DefaultCertWarnDays = 30
+	DefaultCertBadDays  = 7
+)
+
+// CertProbe is a health.Checker for the expiry of the certificates presented by a TLS endpoint,
+// or in a PEM file. Its Value is the number of days until the first certificate expires, with
+// WarnUnder and BadUnder as thresholds. Certificates that do not chain to a trusted root, or
+// do not match the server name, are CRITICAL.
+type CertProbe struct {
+	// Address, if set, is the "host:port" of a TLS endpoint to check
+	Address string
+	// File, if set and Address is not, is the path to a PEM file to check. The first
+	// certificate is the leaf, and any others are intermediates
+	File string
+	// Config provides the RootCAs and ServerName to verify against. For endpoints, the
+	// ServerName defaults to the host of Address, and for files the name is only verified if
+	// ServerName is set. If InsecureSkipVerify is set, only expiry is checked
+	Config *tls.Config
+	// WarnUnder is the number of days until expiry under which the probe is WARNING
+	WarnUnder float64
+	// BadUnder is the number of days until expiry under which the probe is CRITICAL
+	BadUnder float64
+	// Dialer is used to dial Address. If nil, a zero net.Dialer is used
+	Dialer *net.Dialer
+
+	name string
+}
+
+// NewCertProbe returns a CertProbe with the specified name, for the TLS endpoint at address,
+// with the default thresholds
+func NewCertProbe(name, address string) *CertProbe {
+	return &CertProbe{
+		Address:   address,
+		WarnUnder: DefaultCertWarnDays,
+		BadUnder:  DefaultCertBadDays,
+		name:      name,
+	}
+}
+
+// NewCertFileProbe returns a CertProbe with the specified name, for the PEM file at path,
+// with the default thresholds
+func NewCertFileProbe(name, path string) *CertProbe {
+	return &CertProbe{
+		File:      path,
+		WarnUnder: DefaultCertWarnDays,
+		BadUnder:  DefaultCertBadDays,
+		name:      name,
+	}
+}
+
+// Name returns the name of the CertProbe
+func (p *CertProbe) Name() string {
+	return p.name
+}
+
+// Run retrieves the certificates, verifies them, and checks their expiry
+func (p *CertProbe) Run(ctx context.Context) health.Status {
+	now := time.Now()
+	st := health.Status{
+		Name:      p.name,
+		TimeStamp: &now,
+	}
+	if p.WarnUnder > 0 {
+		st.WarnUnder = p.WarnUnder
+	}
+	if p.BadUnder > 0 {
+		st.BadUnder = p.BadUnder
+	}
+
+	config := p.Config
+	if config == nil {
+		config = &tls.Config{}
+	}
+
+	certs, serverName, err := p.certificates(ctx, config)
+	if err != nil {
+		st.Status = health.CRITICAL
+		st.Error = err.Error()
+		return st
+	}
+
+	first := certs[0]
+	for _, c := range certs[1:] {
+		if c.NotAfter.Before(first.NotAfter) {
+			first = c
+		}
+	}
+	days := round2(first.NotAfter.Sub(now).Hours() / 24)
+	st.Value = days
+	st.Message = fmt.Sprintf("'%s' expires in %g days, on %s", first.Subject.CommonName, days, first.NotAfter.UTC().Format(time.RFC3339))
+
+	var verr error
+	if !config.InsecureSkipVerify {
+		verr = verify(certs, config.RootCAs, serverName, now)
+	}
+
+	switch {
+	case days <= 0:
+		st.Status = health.CRITICAL
+		st.Error = "certificate has expired"
+	case verr != nil:
+		st.Status = health.CRITICAL
+		st.Error = verr.Error()
+	default:
+		st.Status = underStatus(days, p.WarnUnder, p.BadUnder)
+	}
+	return st
+}
+
+// certificates returns the certificates from the endpoint or file, leaf first, and the server
+// name they should be verified against
+func (p *CertProbe) certificates(ctx context.Context, config *tls.Config) ([]*x509.Certificate, string, error) {
+	if p.Address == "" {
+		certs, err := readCertificates(p.File)
+		return certs, config.ServerName, err
+	}
+
+	// Verification is done afterwards, so failures can be described
+	insecure := config.Clone()
+	insecure.InsecureSkipVerify = true
+	conn, err := dialTLS(ctx, p.Dialer, insecure, p.Address)
+	if err != nil {
+		return nil, "", err
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, "", errors.New("no certificates presented")
+	}
+	serverName := config.ServerName
+	if serverName == "" {
+		serverName, _, _ = net.SplitHostPort(p.Address)
+	}
+	return certs, serverName, nil
+}
+
+// readCertificates returns the certificates in the PEM file
+func readCertificates(path string) ([]*x509.Certificate, error) {
+	if path == "" {
+		return nil, errors.New("no address or file to check")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate in %s: %w", path, err)
+		}
+		certs = append(certs, c)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates in %s", path)
+	}
+	return certs, nil
+}
+
+// verify returns a descriptive error if the certificates do not chain to roots (or the system
+// roots, if nil), or the leaf does not match serverName, if set
+func verify(certs []*x509.Certificate, roots *x509.CertPool, serverName string, now time.Time) error {
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+	if err != nil {
+		return fmt.Errorf("chain validation failed: %w", err)
+	}
+
+	if serverName != "" {
+		if err := certs[0].VerifyHostname(serverName); err != nil {
+			return fmt.Errorf("hostname mismatch: %w", err)
+		}
+	}
+	return nil
+}
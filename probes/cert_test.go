@@ -0,0 +1,171 @@
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCA is a certificate authority for generating test certificates
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+// newTestCA returns a new testCA, or fails the test
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+// issue returns a server certificate for 127.0.0.1 and "localhost", expiring in the
+// specified time, or fails the test
+func (ca *testCA) issue(t *testing.T, expires time.Duration) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(expires),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// serveTLS starts a TLS listener presenting the certificate, returning its address
+func serveTLS(t *testing.T, cert tls.Certificate) string {
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func Test_CertProbe(t *testing.T) {
+	ca := newTestCA(t)
+	day := 24 * time.Hour
+
+	Convey("When a CertProbe checks an endpoint, the days until expiry determine the status", t, func() {
+		for _, tc := range []struct {
+			expires time.Duration
+			status  health.Severity
+		}{
+			{90 * day, health.OK},
+			{20 * day, health.WARNING},
+			{3 * day, health.CRITICAL},
+		} {
+			p := NewCertProbe("cert", serveTLS(t, ca.issue(t, tc.expires)))
+			p.Config = &tls.Config{RootCAs: ca.pool}
+
+			st := p.Run(context.Background())
+			So(st.Status, ShouldEqual, tc.status)
+			So(st.Error, ShouldBeEmpty)
+			So(st.Value, ShouldAlmostEqual, tc.expires.Hours()/24, 0.01)
+			So(st.WarnUnder, ShouldEqual, DefaultCertWarnDays)
+			So(st.BadUnder, ShouldEqual, DefaultCertBadDays)
+			So(st.Message, ShouldStartWith, "'localhost' expires in ")
+		}
+	})
+
+	Convey("When a CertProbe checks an endpoint that is not trusted, it is CRITICAL with the reason", t, func() {
+		addr := serveTLS(t, ca.issue(t, 90*day))
+
+		st := NewCertProbe("cert", addr).Run(context.Background())
+		So(st.Name, ShouldEqual, "cert")
+		So(st.Status, ShouldEqual, health.CRITICAL)
+		So(st.Error, ShouldStartWith, "chain validation failed: ")
+		So(st.Value, ShouldBeGreaterThan, 89)
+
+		p := NewCertProbe("cert", addr)
+		p.Config = &tls.Config{RootCAs: ca.pool, ServerName: "example.com"}
+		st = p.Run(context.Background())
+		So(st.Status, ShouldEqual, health.CRITICAL)
+		So(st.Error, ShouldStartWith, "hostname mismatch: ")
+
+		p.Config.InsecureSkipVerify = true
+		So(p.Run(context.Background()).Status, ShouldEqual, health.OK)
+	})
+
+	Convey("When a CertProbe checks a PEM file, it is checked the same way", t, func() {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "cert.pem")
+		cert := ca.issue(t, 5*day)
+		err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0600)
+		So(err, ShouldBeNil)
+
+		p := NewCertFileProbe("file", path)
+		p.Config = &tls.Config{RootCAs: ca.pool}
+		st := p.Run(context.Background())
+		So(st.Status, ShouldEqual, health.CRITICAL)
+		So(st.Error, ShouldBeEmpty)
+		So(st.Value, ShouldAlmostEqual, 5, 0.01)
+
+		p.Config.ServerName = "example.com"
+		So(p.Run(context.Background()).Error, ShouldStartWith, "hostname mismatch: ")
+
+		st = NewCertFileProbe("missing", filepath.Join(dir, "missing.pem")).Run(context.Background())
+		So(st.Status, ShouldEqual, health.CRITICAL)
+		So(st.Error, ShouldNotBeEmpty)
+		So(st.Value, ShouldBeNil)
+	})
+}
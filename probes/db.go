@@ -0,0 +1,112 @@
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// DefaultDBTimeout is how long a DBProbe waits for a ping, if it has no Timeout
+const DefaultDBTimeout = 5 * time.Second
+
+// DBProbe is a health.Checker that pings a database. It also reports the sql.DBStats of the
+// database as metrics, named "<name>.<stat>".
+type DBProbe struct {
+	// DB is the database to check
+	DB *sql.DB
+	// Timeout is how long to wait for a ping. If 0, DefaultDBTimeout is used
+	Timeout time.Duration
+	// Latency is the thresholds for how long the ping takes
+	Latency Latency
+	// OpenConnections is the thresholds for the number of open connections
+	OpenConnections Thresholds
+	// InUse is the thresholds for the number of connections in use
+	InUse Thresholds
+	// Idle is the thresholds for the number of idle connections
+	Idle Thresholds
+	// WaitCount is the thresholds for the total number of times a connection was waited for
+	WaitCount Thresholds
+	// WaitDuration is the thresholds for the total time spent waiting for connections, in milliseconds
+	WaitDuration Thresholds
+
+	name string
+}
+
+// NewDBProbe returns a DBProbe with the specified name, for db
+func NewDBProbe(name string, db *sql.DB) *DBProbe {
+	return &DBProbe{
+		DB:   db,
+		name: name,
+	}
+}
+
+// Name returns the name of the DBProbe
+func (p *DBProbe) Name() string {
+	return p.name
+}
+
+// Run pings the database, with the Timeout
+func (p *DBProbe) Run(ctx context.Context) health.Status {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultDBTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := errors.New("no database")
+	if p.DB != nil {
+		err = p.DB.PingContext(ctx)
+	}
+	return p.Latency.status(p.name, start, time.Since(start), err)
+}
+
+// Metrics returns metric Statuses for the sql.DBStats of the database
+func (p *DBProbe) Metrics() []health.Status {
+	if p.DB == nil {
+		return nil
+	}
+
+	stats := p.DB.Stats()
+	metrics := []health.Status{
+		p.OpenConnections.metric(p.name+".open_connections", stats.OpenConnections, ""),
+		p.InUse.metric(p.name+".in_use", stats.InUse, ""),
+		p.Idle.metric(p.name+".idle", stats.Idle, ""),
+		p.WaitCount.metric(p.name+".wait_count", stats.WaitCount, "c"),
+		p.WaitDuration.metric(p.name+".wait_duration", milliseconds(stats.WaitDuration), "ms"),
+	}
+	if stats.MaxOpenConnections > 0 {
+		for i := range metrics[:3] {
+			metrics[i].MinValue = 0
+			metrics[i].MaxValue = stats.MaxOpenConnections
+		}
+	}
+	return metrics
+}
+
+// Record Runs the DBProbe, and sets its result in the StatusRegistry as a system, and its
+// Metrics as metrics. If any cannot be set, such as when the DBProbe has no name, the rest are
+// still set, and the first error is returned.
+func (p *DBProbe) Record(ctx context.Context, sr *health.StatusRegistry) error {
+	err := sr.SetCategorized(health.SystemCategory, p.Run(ctx))
+	for _, m := range p.Metrics() {
+		if merr := sr.SetCategorized(health.MetricCategory, m); err == nil {
+			err = merr
+		}
+	}
+	return err
+}
+
+// AddTo Runs the DBProbe, and adds its result to the Check as a System, and its Metrics as Metrics
+func (p *DBProbe) AddTo(ctx context.Context, hc *health.Check) {
+	st := p.Run(ctx)
+	hc.AddSystem(&st)
+	metrics := p.Metrics()
+	for i := range metrics {
+		hc.AddMetric(&metrics[i])
+	}
+}
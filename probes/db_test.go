@@ -0,0 +1,133 @@
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeDriver is an in-process database/sql driver. Connections to the DSN "down" fail
+// to ping, and to "slow" ping until their context is done.
+type fakeDriver struct{}
+
+type fakeConn struct {
+	dsn string
+}
+
+func init() {
+	sql.Register("probesfake", fakeDriver{})
+}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	return &fakeConn{dsn: dsn}, nil
+}
+
+func (c *fakeConn) Ping(ctx context.Context) error {
+	switch c.dsn {
+	case "down":
+		return errors.New("connection refused")
+	case "slow":
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not implemented")
+}
+
+func Test_DBProbe(t *testing.T) {
+
+	Convey("When a DBProbe pings a healthy database, it is OK", t, func() {
+		db, err := sql.Open("probesfake", "up")
+		So(err, ShouldBeNil)
+		defer db.Close()
+		db.SetMaxOpenConns(10)
+
+		p := NewDBProbe("db", db)
+		st := p.Run(context.Background())
+		So(st.Name, ShouldEqual, "db")
+		So(st.Status, ShouldEqual, health.OK)
+		So(st.Suffix, ShouldEqual, "ms")
+
+		Convey("and its DBStats are metrics, with thresholds", func() {
+			p.Idle = Thresholds{WarnOver: 0.5}
+
+			metrics := p.Metrics()
+			So(len(metrics), ShouldEqual, 5)
+			So(metrics[0].Name, ShouldEqual, "db.open_connections")
+			So(metrics[0].Value, ShouldEqual, 1)
+			So(metrics[0].MaxValue, ShouldEqual, 10)
+			So(metrics[2].Name, ShouldEqual, "db.idle")
+			So(metrics[2].WarnOver, ShouldEqual, 0.5)
+			So(metrics[3].Suffix, ShouldEqual, "c")
+			So(metrics[4].Name, ShouldEqual, "db.wait_duration")
+
+			hc := health.NewCheck()
+			p.AddTo(context.Background(), &hc)
+			hc.Calculate()
+			So(len(hc.Systems), ShouldEqual, 1)
+			So(len(hc.Metrics), ShouldEqual, 5)
+			So(hc.OverallStatus, ShouldEqual, health.WARNING)
+		})
+
+		Convey("and Record stores the results in a StatusRegistry", func() {
+			sr := health.NewStatusRegistry()
+			So(p.Record(context.Background(), sr), ShouldBeNil)
+
+			cat, err := sr.Category("db")
+			So(err, ShouldBeNil)
+			So(cat, ShouldEqual, health.SystemCategory)
+			cat, err = sr.Category("db.in_use")
+			So(err, ShouldBeNil)
+			So(cat, ShouldEqual, health.MetricCategory)
+			So(sr.Snapshot().OverallStatus, ShouldEqual, health.OK)
+		})
+
+		Convey("and Record returns the error when the DBProbe has no name", func() {
+			sr := health.NewStatusRegistry()
+			So(NewDBProbe("", db).Record(context.Background(), sr), ShouldEqual, health.ErrNoNameError)
+		})
+	})
+
+	Convey("When a DBProbe pings an unhealthy database, it is CRITICAL with the error", t, func() {
+		db, err := sql.Open("probesfake", "down")
+		So(err, ShouldBeNil)
+		defer db.Close()
+
+		st := NewDBProbe("db", db).Run(context.Background())
+		So(st.Status, ShouldEqual, health.CRITICAL)
+		So(st.Error, ShouldEqual, "connection refused")
+
+		st = NewDBProbe("db", nil).Run(context.Background())
+		So(st.Status, ShouldEqual, health.CRITICAL)
+		So(NewDBProbe("db", nil).Metrics(), ShouldBeEmpty)
+	})
+
+	Convey("When a DBProbe ping takes longer than the Timeout, it is CRITICAL", t, func() {
+		db, err := sql.Open("probesfake", "slow")
+		So(err, ShouldBeNil)
+		defer db.Close()
+
+		p := NewDBProbe("db", db)
+		p.Timeout = 20 * time.Millisecond
+		st := p.Run(context.Background())
+		So(st.Status, ShouldEqual, health.CRITICAL)
+		So(st.Error, ShouldContainSubstring, "deadline exceeded")
+	})
+}
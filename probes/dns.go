@@ -0,0 +1,57 @@
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// DNSProbe is a health.Checker that resolves a host name
+type DNSProbe struct {
+	// Host is the name to resolve
+	Host string
+	// Latency is the thresholds for how long the resolution takes
+	Latency Latency
+	// Resolver is used to resolve Host. If nil, net.DefaultResolver is used
+	Resolver *net.Resolver
+
+	name string
+}
+
+// NewDNSProbe returns a DNSProbe with the specified name, that resolves host
+func NewDNSProbe(name, host string) *DNSProbe {
+	return &DNSProbe{
+		Host: host,
+		name: name,
+	}
+}
+
+// Name returns the name of the DNSProbe
+func (p *DNSProbe) Name() string {
+	return p.name
+}
+
+// Run resolves Host. It is CRITICAL if Host does not resolve to any addresses.
+func (p *DNSProbe) Run(ctx context.Context) health.Status {
+	r := p.Resolver
+	if r == nil {
+		r = net.DefaultResolver
+	}
+
+	start := time.Now()
+	addrs, err := r.LookupHost(ctx, p.Host)
+	latency := time.Since(start)
+	if err == nil && len(addrs) == 0 {
+		err = fmt.Errorf("%s resolved to no addresses", p.Host)
+	}
+
+	st := p.Latency.status(p.name, start, latency, err)
+	if err == nil {
+		st.Message = fmt.Sprintf("%s resolved to %s", p.Host, strings.Join(addrs, ", "))
+	}
+	return st
+}
@@ -0,0 +1,24 @@
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"context"
+	"testing"
+)
+
+func Test_DNSProbe(t *testing.T) {
+
+	Convey("When a DNSProbe resolves a name, it is OK", t, func() {
+		st := NewDNSProbe("localhost", "localhost").Run(context.Background())
+		So(st.Status, ShouldEqual, health.OK)
+		So(st.Message, ShouldStartWith, "localhost resolved to ")
+	})
+
+	Convey("When a DNSProbe cannot resolve a name, it is CRITICAL with the error", t, func() {
+		st := NewDNSProbe("invalid", "nothing.invalid").Run(context.Background())
+		So(st.Status, ShouldEqual, health.CRITICAL)
+		So(st.Error, ShouldContainSubstring, "nothing.invalid")
+	})
+}
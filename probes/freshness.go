@@ -0,0 +1,102 @@
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FreshnessProbe is a health.Checker for how recently a file, or the contents of a directory,
+// was modified. Its Value is the age of the newest modification time, in seconds.
+type FreshnessProbe struct {
+	// Path is the file or directory to check. For a directory, the newest modification time
+	// of the directory and the entries in it is used
+	Path string
+	// WarnOver is the age over which the probe is WARNING
+	WarnOver time.Duration
+	// BadOver is the age over which the probe is CRITICAL
+	BadOver time.Duration
+
+	name string
+}
+
+// NewFreshnessProbe returns a FreshnessProbe with the specified name, for path, that is WARNING
+// when it is older than warnOver, and CRITICAL when it is older than badOver
+func NewFreshnessProbe(name, path string, warnOver, badOver time.Duration) *FreshnessProbe {
+	return &FreshnessProbe{
+		Path:     path,
+		WarnOver: warnOver,
+		BadOver:  badOver,
+		name:     name,
+	}
+}
+
+// Name returns the name of the FreshnessProbe
+func (p *FreshnessProbe) Name() string {
+	return p.name
+}
+
+// Run stats Path, and checks its age
+func (p *FreshnessProbe) Run(ctx context.Context) health.Status {
+	now := time.Now()
+	st := health.Status{
+		Name:      p.name,
+		Suffix:    "s",
+		TimeStamp: &now,
+	}
+	if p.WarnOver > 0 {
+		st.WarnOver = p.WarnOver.Seconds()
+	}
+	if p.BadOver > 0 {
+		st.BadOver = p.BadOver.Seconds()
+	}
+
+	mtime, err := newestModTime(p.Path)
+	if err != nil {
+		st.Status = health.CRITICAL
+		st.Error = err.Error()
+		return st
+	}
+
+	age := now.Sub(mtime)
+	if age < 0 {
+		age = 0
+	}
+	st.Value = round2(age.Seconds())
+	st.Status = overStatus(age.Seconds(), p.WarnOver.Seconds(), p.BadOver.Seconds())
+	st.Message = fmt.Sprintf("%s modified %s ago", p.Path, age.Round(time.Second))
+	return st
+}
+
+// newestModTime returns the modification time of path, or for a directory, the newest
+// modification time of it and the entries in it
+func newestModTime(path string) (time.Time, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	newest := fi.ModTime()
+	if !fi.IsDir() {
+		return newest, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, e := range entries {
+		efi, err := os.Stat(filepath.Join(path, e.Name()))
+		if err != nil {
+			// Removed since it was listed, or a dangling link
+			continue
+		}
+		if efi.ModTime().After(newest) {
+			newest = efi.ModTime()
+		}
+	}
+	return newest, nil
+}
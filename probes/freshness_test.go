@@ -0,0 +1,57 @@
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_FreshnessProbe(t *testing.T) {
+
+	Convey("When a FreshnessProbe checks a file, its age is the Value", t, func() {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "heartbeat")
+		So(os.WriteFile(file, []byte("ok"), 0600), ShouldBeNil)
+
+		p := NewFreshnessProbe("heartbeat", file, time.Minute, time.Hour)
+		st := p.Run(context.Background())
+		So(st.Name, ShouldEqual, "heartbeat")
+		So(st.Status, ShouldEqual, health.OK)
+		So(st.Value, ShouldBeLessThan, 60)
+		So(st.Suffix, ShouldEqual, "s")
+		So(st.WarnOver, ShouldEqual, 60)
+		So(st.BadOver, ShouldEqual, 3600)
+
+		Convey("and it is WARNING or CRITICAL when it is old", func() {
+			old := time.Now().Add(-10 * time.Minute)
+			So(os.Chtimes(file, old, old), ShouldBeNil)
+			So(os.Chtimes(dir, old, old), ShouldBeNil)
+			So(p.Run(context.Background()).Status, ShouldEqual, health.WARNING)
+
+			old = time.Now().Add(-2 * time.Hour)
+			So(os.Chtimes(file, old, old), ShouldBeNil)
+			So(p.Run(context.Background()).Status, ShouldEqual, health.CRITICAL)
+
+			Convey("and a directory is as fresh as its newest entry", func() {
+				So(os.Chtimes(dir, old, old), ShouldBeNil)
+				d := NewFreshnessProbe("spool", dir, time.Minute, time.Hour)
+				So(d.Run(context.Background()).Status, ShouldEqual, health.CRITICAL)
+
+				So(os.WriteFile(filepath.Join(dir, "new"), []byte("ok"), 0600), ShouldBeNil)
+				So(os.Chtimes(dir, old, old), ShouldBeNil)
+				So(d.Run(context.Background()).Status, ShouldEqual, health.OK)
+			})
+		})
+	})
+
+	Convey("When a FreshnessProbe checks a missing file, it is CRITICAL with the error", t, func() {
+		st := NewFreshnessProbe("missing", filepath.Join(t.TempDir(), "nope"), time.Minute, time.Hour).Run(context.Background())
+		So(st.Status, ShouldEqual, health.CRITICAL)
+		So(st.Error, ShouldNotBeEmpty)
+	})
+}
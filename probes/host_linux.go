@@ -0,0 +1,466 @@
+//go:build linux
+
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Default thresholds for host probes
+const (
+	// DefaultDiskWarnPercent is the percentage of space or inodes used over which a DiskProbe
+	// or InodeProbe is WARNING
+	DefaultDiskWarnPercent = 85
+	// DefaultDiskBadPercent is the percentage of space or inodes used over which a DiskProbe
+	// or InodeProbe is CRITICAL
+	DefaultDiskBadPercent = 95
+	// DefaultLoadWarn is the load average per CPU over which a LoadProbe is WARNING
+	DefaultLoadWarn = 2
+	// DefaultLoadBad is the load average per CPU over which a LoadProbe is CRITICAL
+	DefaultLoadBad = 4
+	// DefaultMemoryWarnPercent is the percentage of memory available under which a
+	// MemoryProbe is WARNING
+	DefaultMemoryWarnPercent = 10
+	// DefaultMemoryBadPercent is the percentage of memory available under which a
+	// MemoryProbe is CRITICAL
+	DefaultMemoryBadPercent = 5
+)
+
+// skipFSTypes are the filesystem types MountPoints omits without statfs'ing them: pseudo-
+// filesystems, which have no space, and network and FUSE filesystems, whose statfs can hang
+// when their server is unreachable
+var skipFSTypes = map[string]bool{
+	"autofs": true, "binfmt_misc": true, "bpf": true, "cgroup": true, "cgroup2": true,
+	"configfs": true, "debugfs": true, "devpts": true, "efivarfs": true, "fusectl": true,
+	"hugetlbfs": true, "mqueue": true, "nsfs": true, "proc": true, "pstore": true,
+	"rpc_pipefs": true, "securityfs": true, "selinuxfs": true, "sysfs": true, "tracefs": true,
+
+	"9p": true, "afs": true, "ceph": true, "cifs": true, "fuse": true, "glusterfs": true,
+	"lustre": true, "ncpfs": true, "nfs": true, "nfs4": true, "smb3": true, "smbfs": true,
+	"sshfs": true,
+}
+
+// The proc files read by host probes, variables for testing
+var (
+	procLoadAvg = "/proc/loadavg"
+	procMemInfo = "/proc/meminfo"
+	procMounts  = "/proc/self/mounts"
+)
+
+// DiskProbe is a health.Checker for the space used on the filesystem containing a path. Its
+// Value is the percentage used, of the space available to unprivileged users, as df reports it.
+type DiskProbe struct {
+	// Path is a path on the filesystem to check, usually its mount point
+	Path string
+	// WarnOver is the percentage used over which the probe is WARNING
+	WarnOver float64
+	// BadOver is the percentage used over which the probe is CRITICAL
+	BadOver float64
+
+	name string
+}
+
+// NewDiskProbe returns a DiskProbe with the specified name, for the filesystem containing path,
+// with the default thresholds
+func NewDiskProbe(name, path string) *DiskProbe {
+	return &DiskProbe{
+		Path:     path,
+		WarnOver: DefaultDiskWarnPercent,
+		BadOver:  DefaultDiskBadPercent,
+		name:     name,
+	}
+}
+
+// Name returns the name of the DiskProbe
+func (p *DiskProbe) Name() string {
+	return p.name
+}
+
+// Run statfs's Path, and checks the space used
+func (p *DiskProbe) Run(ctx context.Context) health.Status {
+	st := percentStatus(p.name, p.WarnOver, p.BadOver)
+
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(p.Path, &fs); err != nil {
+		st.Status = health.CRITICAL
+		st.Error = err.Error()
+		return st
+	}
+
+	bsize := uint64(fs.Bsize)
+	used := (fs.Blocks - fs.Bfree) * bsize
+	avail := fs.Bavail * bsize
+	pct := usedPercent(used, avail)
+	st.Value = pct
+	st.Status = overStatus(pct, p.WarnOver, p.BadOver)
+	st.Message = fmt.Sprintf("%s: %s used, %s available", p.Path, bytesString(used), bytesString(avail))
+	return st
+}
+
+// InodeProbe is a health.Checker for the inodes used on the filesystem containing a path. Its
+// Value is the percentage used.
+type InodeProbe struct {
+	// Path is a path on the filesystem to check, usually its mount point
+	Path string
+	// WarnOver is the percentage used over which the probe is WARNING
+	WarnOver float64
+	// BadOver is the percentage used over which the probe is CRITICAL
+	BadOver float64
+
+	name string
+}
+
+// NewInodeProbe returns an InodeProbe with the specified name, for the filesystem containing
+// path, with the default thresholds
+func NewInodeProbe(name, path string) *InodeProbe {
+	return &InodeProbe{
+		Path:     path,
+		WarnOver: DefaultDiskWarnPercent,
+		BadOver:  DefaultDiskBadPercent,
+		name:     name,
+	}
+}
+
+// Name returns the name of the InodeProbe
+func (p *InodeProbe) Name() string {
+	return p.name
+}
+
+// Run statfs's Path, and checks the inodes used. Filesystems without a fixed number of inodes
+// are OK.
+func (p *InodeProbe) Run(ctx context.Context) health.Status {
+	st := percentStatus(p.name, p.WarnOver, p.BadOver)
+
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(p.Path, &fs); err != nil {
+		st.Status = health.CRITICAL
+		st.Error = err.Error()
+		return st
+	}
+
+	used := fs.Files - fs.Ffree
+	pct := usedPercent(used, fs.Ffree)
+	st.Value = pct
+	st.Status = overStatus(pct, p.WarnOver, p.BadOver)
+	st.Message = fmt.Sprintf("%s: %d of %d inodes used", p.Path, used, fs.Files)
+	return st
+}
+
+// percentStatus returns a Status for the named probe, whose Value is a percentage with
+// high-side thresholds
+func percentStatus(name string, warnOver, badOver float64) health.Status {
+	now := time.Now()
+	st := health.Status{
+		Name:      name,
+		Suffix:    "%",
+		MinValue:  0,
+		MaxValue:  100,
+		TimeStamp: &now,
+	}
+	if warnOver > 0 {
+		st.WarnOver = warnOver
+	}
+	if badOver > 0 {
+		st.BadOver = badOver
+	}
+	return st
+}
+
+// usedPercent returns used as a percentage of used and available, or 0 if both are 0
+func usedPercent(used, avail uint64) float64 {
+	if used+avail == 0 {
+		return 0
+	}
+	return round2(float64(used) / float64(used+avail) * 100)
+}
+
+// bytesString returns b in the largest binary unit that keeps it at least 1
+func bytesString(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// MountPoints returns the mount points of the local filesystems that have space, from
+// /proc/self/mounts. Pseudo-filesystems, such as proc and sysfs, and network and FUSE
+// filesystems, such as nfs and fuse.sshfs, are omitted by type. The rest are statfs'd to see
+// if they have space, which can still block if the filesystem is unresponsive, or of a network
+// type that is not known, so MountPoints should not be called while holding anything important.
+func MountPoints() ([]string, error) {
+	f, err := os.Open(procMounts)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		mounts []string
+		seen   = make(map[string]bool)
+	)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 3 || skipFSType(fields[2]) {
+			continue
+		}
+		mp := unescapeMount(fields[1])
+		if seen[mp] {
+			continue
+		}
+		seen[mp] = true
+
+		var fs syscall.Statfs_t
+		if err := syscall.Statfs(mp, &fs); err != nil || fs.Blocks == 0 {
+			continue
+		}
+		mounts = append(mounts, mp)
+	}
+	return mounts, s.Err()
+}
+
+// skipFSType returns true if MountPoints should omit filesystems of the type
+func skipFSType(fstype string) bool {
+	if i := strings.IndexByte(fstype, '.'); i >= 0 {
+		// Subtypes, such as fuse.sshfs
+		fstype = fstype[:i]
+	}
+	return skipFSTypes[fstype]
+}
+
+// unescapeMount returns the path from /proc/self/mounts with its octal escapes, such as \040
+// for a space, replaced
+func unescapeMount(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+4 <= len(path) {
+			if c, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
+
+// LoadProbe is a health.Checker for the load average of the host. Its Value is the load
+// average over Minutes, divided by the number of CPUs if PerCPU is set.
+type LoadProbe struct {
+	// Minutes is the load average to check: 1, 5, or 15
+	Minutes int
+	// PerCPU divides the load average by the number of CPUs
+	PerCPU bool
+	// WarnOver is the load over which the probe is WARNING
+	WarnOver float64
+	// BadOver is the load over which the probe is CRITICAL
+	BadOver float64
+
+	name string
+}
+
+// NewLoadProbe returns a LoadProbe with the specified name, for the 5 minute load average per
+// CPU, with the default thresholds
+func NewLoadProbe(name string) *LoadProbe {
+	return &LoadProbe{
+		Minutes:  5,
+		PerCPU:   true,
+		WarnOver: DefaultLoadWarn,
+		BadOver:  DefaultLoadBad,
+		name:     name,
+	}
+}
+
+// Name returns the name of the LoadProbe
+func (p *LoadProbe) Name() string {
+	return p.name
+}
+
+// Run reads the load averages, and checks the one for Minutes
+func (p *LoadProbe) Run(ctx context.Context) health.Status {
+	now := time.Now()
+	st := health.Status{
+		Name:      p.name,
+		TimeStamp: &now,
+	}
+	if p.WarnOver > 0 {
+		st.WarnOver = p.WarnOver
+	}
+	if p.BadOver > 0 {
+		st.BadOver = p.BadOver
+	}
+
+	loads, err := loadAverages()
+	if err != nil {
+		st.Status = health.CRITICAL
+		st.Error = err.Error()
+		return st
+	}
+
+	var load float64
+	switch p.Minutes {
+	case 1:
+		load = loads[0]
+	case 5:
+		load = loads[1]
+	case 15:
+		load = loads[2]
+	default:
+		st.Status = health.UNKNOWN
+		st.Error = fmt.Sprintf("invalid load average minutes %d", p.Minutes)
+		return st
+	}
+
+	cpus := runtime.NumCPU()
+	if p.PerCPU {
+		load /= float64(cpus)
+	}
+	st.Value = round2(load)
+	st.Status = overStatus(load, p.WarnOver, p.BadOver)
+	st.Message = fmt.Sprintf("load average: %g, %g, %g on %d CPUs", loads[0], loads[1], loads[2], cpus)
+	return st
+}
+
+// loadAverages returns the 1, 5, and 15 minute load averages
+func loadAverages() ([3]float64, error) {
+	var loads [3]float64
+	b, err := os.ReadFile(procLoadAvg)
+	if err != nil {
+		return loads, err
+	}
+
+	fields := strings.Fields(string(b))
+	if len(fields) < 3 {
+		return loads, fmt.Errorf("invalid %s", procLoadAvg)
+	}
+	for i := range loads {
+		if loads[i], err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return loads, fmt.Errorf("invalid %s: %w", procLoadAvg, err)
+		}
+	}
+	return loads, nil
+}
+
+// MemoryProbe is a health.Checker for the memory available on the host, for starting new
+// applications without swapping. Its Value is the percentage of memory available.
+type MemoryProbe struct {
+	// WarnUnder is the percentage available under which the probe is WARNING
+	WarnUnder float64
+	// BadUnder is the percentage available under which the probe is CRITICAL
+	BadUnder float64
+
+	name string
+}
+
+// NewMemoryProbe returns a MemoryProbe with the specified name, with the default thresholds
+func NewMemoryProbe(name string) *MemoryProbe {
+	return &MemoryProbe{
+		WarnUnder: DefaultMemoryWarnPercent,
+		BadUnder:  DefaultMemoryBadPercent,
+		name:      name,
+	}
+}
+
+// Name returns the name of the MemoryProbe
+func (p *MemoryProbe) Name() string {
+	return p.name
+}
+
+// Run reads the memory information, and checks the memory available
+func (p *MemoryProbe) Run(ctx context.Context) health.Status {
+	now := time.Now()
+	st := health.Status{
+		Name:      p.name,
+		Suffix:    "%",
+		MinValue:  0,
+		MaxValue:  100,
+		TimeStamp: &now,
+	}
+	if p.WarnUnder > 0 {
+		st.WarnUnder = p.WarnUnder
+	}
+	if p.BadUnder > 0 {
+		st.BadUnder = p.BadUnder
+	}
+
+	info, err := memInfo()
+	if err != nil {
+		st.Status = health.CRITICAL
+		st.Error = err.Error()
+		return st
+	}
+
+	total := info["MemTotal"]
+	avail, ok := info["MemAvailable"]
+	if !ok {
+		// Kernels before 3.14 do not estimate it
+		avail = info["MemFree"] + info["Buffers"] + info["Cached"]
+	}
+	if total == 0 {
+		st.Status = health.CRITICAL
+		st.Error = fmt.Sprintf("no MemTotal in %s", procMemInfo)
+		return st
+	}
+
+	pct := float64(avail) / float64(total) * 100
+	st.Value = round2(pct)
+	st.Status = underStatus(pct, p.WarnUnder, p.BadUnder)
+	st.Message = fmt.Sprintf("%s available of %s", bytesString(avail), bytesString(total))
+	return st
+}
+
+// memInfo returns the values from /proc/meminfo, in bytes
+func memInfo() (map[string]uint64, error) {
+	f, err := os.Open(procMemInfo)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info := make(map[string]uint64)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// e.g. "MemTotal:       16310560 kB"
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 2 && fields[2] == "kB" {
+			v *= 1024
+		}
+		info[strings.TrimSuffix(fields[0], ":")] = v
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(info) == 0 {
+		return nil, errors.New("no memory information")
+	}
+	return info, nil
+}
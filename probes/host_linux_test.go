@@ -0,0 +1,149 @@
+//go:build linux
+
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// withProcFile points *procFile at a temporary file with the contents, for the duration of the test
+func withProcFile(t *testing.T, procFile *string, contents string) {
+	path := filepath.Join(t.TempDir(), filepath.Base(*procFile))
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	orig := *procFile
+	*procFile = path
+	t.Cleanup(func() { *procFile = orig })
+}
+
+func Test_DiskProbes(t *testing.T) {
+
+	Convey("When a DiskProbe checks a filesystem, the percentage used is the Value", t, func() {
+		p := NewDiskProbe("disk", t.TempDir())
+		st := p.Run(context.Background())
+		So(st.Name, ShouldEqual, "disk")
+		So(st.Error, ShouldBeEmpty)
+		So(st.Suffix, ShouldEqual, "%")
+		So(st.Value, ShouldBeBetweenOrEqual, 0, 100)
+		So(st.WarnOver, ShouldEqual, DefaultDiskWarnPercent)
+	})
+
+	Convey("When the space used is computed, it is a percentage of what is usable", t, func() {
+		So(usedPercent(0, 0), ShouldEqual, 0)
+		So(usedPercent(90, 10), ShouldEqual, 90)
+		So(usedPercent(1, 2), ShouldEqual, 33.33)
+
+		So(overStatus(usedPercent(80, 20), DefaultDiskWarnPercent, DefaultDiskBadPercent), ShouldEqual, health.OK)
+		So(overStatus(usedPercent(90, 10), DefaultDiskWarnPercent, DefaultDiskBadPercent), ShouldEqual, health.WARNING)
+		So(overStatus(usedPercent(96, 4), DefaultDiskWarnPercent, DefaultDiskBadPercent), ShouldEqual, health.CRITICAL)
+		So(overStatus(usedPercent(96, 4), 0, 0), ShouldEqual, health.OK)
+	})
+
+	Convey("When an InodeProbe checks a filesystem, the percentage used is the Value", t, func() {
+		st := NewInodeProbe("inodes", t.TempDir()).Run(context.Background())
+		So(st.Name, ShouldEqual, "inodes")
+		So(st.Error, ShouldBeEmpty)
+		So(st.Value, ShouldBeBetweenOrEqual, 0, 100)
+	})
+
+	Convey("When a DiskProbe checks a missing path, it is CRITICAL with the error", t, func() {
+		st := NewDiskProbe("disk", filepath.Join(t.TempDir(), "nope")).Run(context.Background())
+		So(st.Status, ShouldEqual, health.CRITICAL)
+		So(st.Error, ShouldNotBeEmpty)
+	})
+
+	Convey("When MountPoints are listed, pseudo, network, and FUSE filesystems are omitted", t, func() {
+		dir := t.TempDir()
+		So(os.Mkdir(filepath.Join(dir, "nfs"), 0700), ShouldBeNil)
+		So(os.Mkdir(filepath.Join(dir, "sshfs"), 0700), ShouldBeNil)
+		withProcFile(t, &procMounts, "proc /proc proc rw 0 0\n"+
+			"server:/export "+dir+"/nfs nfs4 rw 0 0\n"+
+			"user@host: "+dir+"/sshfs fuse.sshfs rw 0 0\n"+
+			"/dev/sda1 "+dir+" ext4 rw 0 0\n"+
+			"/dev/sda1 "+dir+" ext4 rw 0 0\n")
+
+		mounts, err := MountPoints()
+		So(err, ShouldBeNil)
+		So(mounts, ShouldResemble, []string{dir})
+
+		So(skipFSType("fuse.sshfs"), ShouldBeTrue)
+		So(skipFSType("fuseblk"), ShouldBeFalse)
+		So(skipFSType("xfs"), ShouldBeFalse)
+
+		So(unescapeMount(`/mnt/my\040disk`), ShouldEqual, "/mnt/my disk")
+		So(unescapeMount(`/mnt/trailing\04`), ShouldEqual, `/mnt/trailing\04`)
+	})
+}
+
+func Test_LoadProbe(t *testing.T) {
+
+	Convey("When a LoadProbe checks the load average, it is the Value", t, func() {
+		withProcFile(t, &procLoadAvg, "0.50 1.00 8.00 1/123 4567\n")
+
+		p := NewLoadProbe("load")
+		p.PerCPU = false
+		st := p.Run(context.Background())
+		So(st.Name, ShouldEqual, "load")
+		So(st.Value, ShouldEqual, 1)
+		So(st.Status, ShouldEqual, health.OK)
+		So(st.Message, ShouldStartWith, "load average: 0.5, 1, 8")
+
+		p.Minutes = 15
+		So(p.Run(context.Background()).Status, ShouldEqual, health.CRITICAL)
+		p.Minutes = 1
+		So(p.Run(context.Background()).Value, ShouldEqual, 0.5)
+		p.Minutes = 10
+		So(p.Run(context.Background()).Status, ShouldEqual, health.UNKNOWN)
+
+		Convey("and PerCPU divides it by the number of CPUs", func() {
+			p.Minutes = 15
+			p.PerCPU = true
+			So(p.Run(context.Background()).Value, ShouldEqual, round2(8/float64(runtime.NumCPU())))
+		})
+	})
+
+	Convey("When a LoadProbe cannot read the load average, it is CRITICAL", t, func() {
+		withProcFile(t, &procLoadAvg, "garbage\n")
+		So(NewLoadProbe("load").Run(context.Background()).Status, ShouldEqual, health.CRITICAL)
+	})
+}
+
+func Test_MemoryProbe(t *testing.T) {
+
+	Convey("When a MemoryProbe checks the memory, the percentage available is the Value", t, func() {
+		withProcFile(t, &procMemInfo, "MemTotal:       1000000 kB\nMemFree:          10000 kB\nMemAvailable:    250000 kB\n")
+
+		p := NewMemoryProbe("memory")
+		st := p.Run(context.Background())
+		So(st.Name, ShouldEqual, "memory")
+		So(st.Value, ShouldEqual, 25)
+		So(st.Status, ShouldEqual, health.OK)
+		So(st.WarnUnder, ShouldEqual, DefaultMemoryWarnPercent)
+
+		p.WarnUnder = 30
+		So(p.Run(context.Background()).Status, ShouldEqual, health.WARNING)
+		p.BadUnder = 26
+		So(p.Run(context.Background()).Status, ShouldEqual, health.CRITICAL)
+	})
+
+	Convey("When MemAvailable is missing, it is estimated", t, func() {
+		withProcFile(t, &procMemInfo, "MemTotal: 1000 kB\nMemFree: 20 kB\nBuffers: 10 kB\nCached: 20 kB\n")
+
+		st := NewMemoryProbe("memory").Run(context.Background())
+		So(st.Value, ShouldEqual, 5)
+		So(st.Status, ShouldEqual, health.WARNING)
+	})
+
+	Convey("When a MemoryProbe cannot read the memory, it is CRITICAL", t, func() {
+		withProcFile(t, &procMemInfo, "")
+		So(NewMemoryProbe("memory").Run(context.Background()).Status, ShouldEqual, health.CRITICAL)
+	})
+}
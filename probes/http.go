@@ -0,0 +1,89 @@
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// maxHTTPBody is the most of a response body that is matched against BodyPattern
+const maxHTTPBody = 1 << 20
+
+// HTTPProbe is a health.Checker that GETs a URL
+type HTTPProbe struct {
+	// URL is the URL to GET
+	URL string
+	// ExpectedStatus is the HTTP status code the response must have. If 0, any 2xx is accepted
+	ExpectedStatus int
+	// BodyPattern, if set, must match the response body
+	BodyPattern *regexp.Regexp
+	// Latency is the thresholds for how long the request takes, including reading the body
+	Latency Latency
+	// Client is used to make the request. If nil, http.DefaultClient is used
+	Client *http.Client
+
+	name string
+}
+
+// NewHTTPProbe returns an HTTPProbe with the specified name, that GETs url
+func NewHTTPProbe(name, url string) *HTTPProbe {
+	return &HTTPProbe{
+		URL:  url,
+		name: name,
+	}
+}
+
+// Name returns the name of the HTTPProbe
+func (p *HTTPProbe) Name() string {
+	return p.name
+}
+
+// Run GETs URL, and checks the response status code and body
+func (p *HTTPProbe) Run(ctx context.Context) health.Status {
+	start := time.Now()
+	status, err := p.get(ctx)
+	st := p.Latency.status(p.name, start, time.Since(start), err)
+	if err == nil {
+		st.Message = status
+	}
+	return st
+}
+
+// get makes the request, returning the response status, or an error if the response is not
+// as expected
+func (p *HTTPProbe) get(ctx context.Context) (string, error) {
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if p.ExpectedStatus != 0 && resp.StatusCode != p.ExpectedStatus {
+		return "", fmt.Errorf("unexpected status %s, expected %d", resp.Status, p.ExpectedStatus)
+	} else if p.ExpectedStatus == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299) {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBody))
+	if err != nil {
+		return "", fmt.Errorf("read failed: %w", err)
+	}
+	if p.BodyPattern != nil && !p.BodyPattern.Match(body) {
+		return "", fmt.Errorf("body does not match '%s'", p.BodyPattern)
+	}
+	return resp.Status, nil
+}
@@ -0,0 +1,69 @@
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func Test_HTTPProbe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte(`{"overallStatus":"OK"}`))
+		case "/teapot":
+			w.WriteHeader(http.StatusTeapot)
+		case "/slow":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	Convey("When an HTTPProbe GETs a healthy URL, it is OK", t, func() {
+		p := NewHTTPProbe("ok", srv.URL+"/ok")
+		p.BodyPattern = regexp.MustCompile(`"overallStatus":"OK"`)
+
+		st := p.Run(context.Background())
+		So(st.Status, ShouldEqual, health.OK)
+		So(st.Message, ShouldEqual, "200 OK")
+		So(st.Value, ShouldBeGreaterThan, 0)
+	})
+
+	Convey("When an HTTPProbe gets an unexpected response, it is CRITICAL with the reason", t, func() {
+		st := NewHTTPProbe("missing", srv.URL+"/missing").Run(context.Background())
+		So(st.Status, ShouldEqual, health.CRITICAL)
+		So(st.Error, ShouldEqual, "unexpected status 404 Not Found")
+
+		p := NewHTTPProbe("ok", srv.URL+"/ok")
+		p.BodyPattern = regexp.MustCompile(`CRITICAL`)
+		st = p.Run(context.Background())
+		So(st.Status, ShouldEqual, health.CRITICAL)
+		So(st.Error, ShouldContainSubstring, "body does not match")
+
+		p = NewHTTPProbe("teapot", srv.URL+"/teapot")
+		p.ExpectedStatus = http.StatusTeapot
+		So(p.Run(context.Background()).Status, ShouldEqual, health.OK)
+		p.ExpectedStatus = http.StatusOK
+		So(p.Run(context.Background()).Error, ShouldContainSubstring, "expected 200")
+	})
+
+	Convey("When an HTTPProbe is canceled, it is CRITICAL", t, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		st := NewHTTPProbe("slow", srv.URL+"/slow").Run(ctx)
+		So(st.Status, ShouldEqual, health.CRITICAL)
+		So(st.Error, ShouldContainSubstring, "deadline exceeded")
+	})
+}
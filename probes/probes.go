@@ -0,0 +1,111 @@
+// Package probes provides health.Checkers for common dependencies: TCP services, HTTP(S)
+// endpoints, DNS names, and TLS handshakes. Each produces a health.Status with the latency of
+// the probe, in milliseconds, as its Value, and the reason for any failure as its Error.
+//
+// It also provides Checkers for the resources of the host: the freshness of files, and on Linux,
+// disk space and inode usage, load average, and available memory. These produce a health.Status
+// with the measurement, and its Suffix, as its Value.
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+
+	"math"
+	"time"
+)
+
+// Latency is the thresholds for the latency of a probe. Zero thresholds are not checked.
+type Latency struct {
+	// WarnOver is the latency over which the probe is WARNING
+	WarnOver time.Duration
+	// BadOver is the latency over which the probe is CRITICAL
+	BadOver time.Duration
+}
+
+// status returns a Status for the named probe that started at start and took latency. If err
+// is not nil, the Status is CRITICAL with err as its Error, otherwise its Status is determined
+// by the latency thresholds.
+func (l *Latency) status(name string, start time.Time, latency time.Duration, err error) health.Status {
+	st := health.Status{
+		Name:      name,
+		Status:    health.OK,
+		Value:     milliseconds(latency),
+		Suffix:    "ms",
+		TimeStamp: &start,
+	}
+	if l.WarnOver > 0 {
+		st.WarnOver = milliseconds(l.WarnOver)
+	}
+	if l.BadOver > 0 {
+		st.BadOver = milliseconds(l.BadOver)
+	}
+
+	switch {
+	case err != nil:
+		st.Status = health.CRITICAL
+		st.Error = err.Error()
+	case l.BadOver > 0 && latency > l.BadOver:
+		st.Status = health.CRITICAL
+	case l.WarnOver > 0 && latency > l.WarnOver:
+		st.Status = health.WARNING
+	}
+	return st
+}
+
+// milliseconds returns the Duration as fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Thresholds is the thresholds for a metric. Zero thresholds are not checked.
+type Thresholds struct {
+	// WarnOver is the value over which the metric is WARNING
+	WarnOver float64
+	// BadOver is the value over which the metric is CRITICAL
+	BadOver float64
+}
+
+// metric returns a metric Status with the name and value, and the thresholds
+func (t *Thresholds) metric(name string, value interface{}, suffix string) health.Status {
+	st := health.Status{
+		Name:   name,
+		Value:  value,
+		Suffix: suffix,
+	}
+	if t.WarnOver > 0 {
+		st.WarnOver = t.WarnOver
+	}
+	if t.BadOver > 0 {
+		st.BadOver = t.BadOver
+	}
+	return st
+}
+
+// overStatus returns the status of value according to high-side thresholds. Zero thresholds
+// are not checked.
+func overStatus(value, warnOver, badOver float64) health.Severity {
+	switch {
+	case badOver > 0 && value > badOver:
+		return health.CRITICAL
+	case warnOver > 0 && value > warnOver:
+		return health.WARNING
+	}
+	return health.OK
+}
+
+// underStatus returns the status of value according to low-side thresholds. Zero thresholds
+// are not checked.
+func underStatus(value, warnUnder, badUnder float64) health.Severity {
+	switch {
+	case badUnder > 0 && value < badUnder:
+		return health.CRITICAL
+	case warnUnder > 0 && value < warnUnder:
+		return health.WARNING
+	}
+	return health.OK
+}
+
+// round2 rounds f to 2 decimal places
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
@@ -0,0 +1,267 @@
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+
+	"bufio"
+	"math"
+	"os"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRuntimePrefix is the prefix of the names of RuntimeCollector metrics, if none is set
+const DefaultRuntimePrefix = "runtime"
+
+// runtime/metrics sampled by a RuntimeCollector. The heap in use is the sum of the heap
+// objects and the unused heap, as MemStats.HeapInuse is. gcPausesMetric is only used on
+// runtimes that predate schedPausesMetric.
+const (
+	goroutinesMetric  = "/sched/goroutines:goroutines"
+	heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+	heapUnusedMetric  = "/memory/classes/heap/unused:bytes"
+	schedPausesMetric = "/sched/pauses/total/gc:seconds"
+	gcPausesMetric    = "/gc/pauses:seconds"
+)
+
+// The proc files read for when the process started, variables for testing
+var (
+	procSelfStat = "/proc/self/stat"
+	procStat     = "/proc/stat"
+)
+
+// clockTicks is the USER_HZ that /proc/self/stat times are in, which is 100 on all common
+// Linux platforms
+const clockTicks = 100
+
+// processStart is when the process started, for uptime, or when the package was loaded, if
+// that is unknown
+var processStart = processStartTime(time.Now())
+
+// RuntimeCollector samples the Go runtime and the process into metric Statuses, named
+// "<Prefix>.<metric>": goroutines, heap_in_use (bytes), gc_pause_p50 and gc_pause_p99
+// (milliseconds), open_fds (where /proc/self is available), and uptime (seconds since the
+// process started, where /proc is available, and otherwise since this package was loaded).
+//
+// The GC pause percentiles are of the pauses since the previous sample, or since the process
+// started for the first, and are 0 if there were none. A RuntimeCollector must not be copied
+// after it is first used.
+type RuntimeCollector struct {
+	// Prefix is the beginning of the name of each metric. If empty, DefaultRuntimePrefix is used
+	Prefix string
+	// Goroutines is the thresholds for the number of goroutines
+	Goroutines Thresholds
+	// HeapInUse is the thresholds for the bytes of heap in use
+	HeapInUse Thresholds
+	// GCPause is the thresholds for the 99th percentile GC pause, in milliseconds
+	GCPause Thresholds
+	// OpenFDs is the thresholds for the number of open file descriptors. If zero, 80% and 95%
+	// of the open files limit are used, if it is known
+	OpenFDs Thresholds
+
+	// lock guards pauses, the GC pause histogram counts of the previous sample
+	lock   sync.Mutex
+	pauses []uint64
+}
+
+// NewRuntimeCollector returns a RuntimeCollector with the default thresholds: WARNING over
+// 10,000 goroutines, or a 100ms GC pause, and CRITICAL over 50,000 goroutines, or a 500ms GC pause
+func NewRuntimeCollector() *RuntimeCollector {
+	return &RuntimeCollector{
+		Goroutines: Thresholds{WarnOver: 10000, BadOver: 50000},
+		GCPause:    Thresholds{WarnOver: 100, BadOver: 500},
+	}
+}
+
+// Metrics samples the runtime and process, and returns metric Statuses. Metrics that cannot be
+// sampled are omitted.
+func (c *RuntimeCollector) Metrics() []health.Status {
+	prefix := c.Prefix
+	if prefix == "" {
+		prefix = DefaultRuntimePrefix
+	}
+	prefix += "."
+
+	samples := []metrics.Sample{
+		{Name: goroutinesMetric},
+		{Name: heapObjectsMetric},
+		{Name: heapUnusedMetric},
+		{Name: schedPausesMetric},
+	}
+	metrics.Read(samples)
+	if samples[3].Value.Kind() == metrics.KindBad {
+		samples[3].Name = gcPausesMetric
+		metrics.Read(samples[3:])
+	}
+
+	var stats []health.Status
+	if s := samples[0].Value; s.Kind() == metrics.KindUint64 {
+		stats = append(stats, c.Goroutines.metric(prefix+"goroutines", s.Uint64(), ""))
+	}
+	if o, u := samples[1].Value, samples[2].Value; o.Kind() == metrics.KindUint64 && u.Kind() == metrics.KindUint64 {
+		stats = append(stats, c.HeapInUse.metric(prefix+"heap_in_use", o.Uint64()+u.Uint64(), "B"))
+	}
+	if s := samples[3].Value; s.Kind() == metrics.KindFloat64Histogram {
+		h := c.pausesSince(s.Float64Histogram())
+		var none Thresholds
+		stats = append(stats,
+			none.metric(prefix+"gc_pause_p50", percentile(h, 0.5)*1000, "ms"),
+			c.GCPause.metric(prefix+"gc_pause_p99", percentile(h, 0.99)*1000, "ms"),
+		)
+	}
+
+	if fds, err := os.ReadDir("/proc/self/fd"); err == nil {
+		t := c.OpenFDs
+		limit, ok := openFilesLimit()
+		if ok && t.WarnOver == 0 && t.BadOver == 0 {
+			t = Thresholds{WarnOver: math.Floor(limit * 0.8), BadOver: math.Floor(limit * 0.95)}
+		}
+		// Less the descriptor ReadDir had open
+		st := t.metric(prefix+"open_fds", len(fds)-1, "")
+		if ok {
+			st.MinValue = 0
+			st.MaxValue = limit
+		}
+		stats = append(stats, st)
+	}
+
+	var none Thresholds
+	stats = append(stats, none.metric(prefix+"uptime", math.Round(time.Since(processStart).Seconds()), "s"))
+	return stats
+}
+
+// Record samples the runtime and process, and sets the Metrics in the StatusRegistry. If any
+// cannot be set, the rest are still set, and the first error is returned.
+func (c *RuntimeCollector) Record(sr *health.StatusRegistry) error {
+	var err error
+	for _, m := range c.Metrics() {
+		if merr := sr.SetCategorized(health.MetricCategory, m); err == nil {
+			err = merr
+		}
+	}
+	return err
+}
+
+// AddTo samples the runtime and process, and adds the Metrics to the Check
+func (c *RuntimeCollector) AddTo(hc *health.Check) {
+	metrics := c.Metrics()
+	for i := range metrics {
+		hc.AddMetric(&metrics[i])
+	}
+}
+
+// pausesSince returns the histogram of the pauses since the previous sample, and keeps h as
+// the previous sample
+func (c *RuntimeCollector) pausesSince(h *metrics.Float64Histogram) *metrics.Float64Histogram {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delta := &metrics.Float64Histogram{
+		Counts:  make([]uint64, len(h.Counts)),
+		Buckets: h.Buckets,
+	}
+	for i, n := range h.Counts {
+		delta.Counts[i] = n
+		if len(c.pauses) == len(h.Counts) && c.pauses[i] <= n {
+			delta.Counts[i] -= c.pauses[i]
+		}
+	}
+	c.pauses = append(c.pauses[:0], h.Counts...)
+	return delta
+}
+
+// percentile returns the upper bound of the bucket containing the pth percentile of the
+// histogram, or 0 if it is empty
+func percentile(h *metrics.Float64Histogram, p float64) float64 {
+	var total uint64
+	for _, n := range h.Counts {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+
+	target := uint64(math.Ceil(float64(total) * p))
+	var seen uint64
+	for i, n := range h.Counts {
+		seen += n
+		if seen >= target {
+			if math.IsInf(h.Buckets[i+1], 1) {
+				return h.Buckets[i]
+			}
+			return h.Buckets[i+1]
+		}
+	}
+	return h.Buckets[len(h.Buckets)-1]
+}
+
+// processStartTime returns when the process started, from the boot time in /proc/stat and the
+// start time in /proc/self/stat, or fallback if either is unavailable
+func processStartTime(fallback time.Time) time.Time {
+	stat, err := os.ReadFile(procSelfStat)
+	if err != nil {
+		return fallback
+	}
+	// The command, in parentheses, may contain spaces, so fields are counted after it
+	i := strings.LastIndexByte(string(stat), ')')
+	if i < 0 {
+		return fallback
+	}
+	fields := strings.Fields(string(stat[i+1:]))
+	// starttime is the 22nd field, and the fields after the command begin with the 3rd
+	if len(fields) < 20 {
+		return fallback
+	}
+	ticks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return fallback
+	}
+
+	f, err := os.Open(procStat)
+	if err != nil {
+		return fallback
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if !strings.HasPrefix(line, "btime ") {
+			continue
+		}
+		btime, err := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(line, "btime ")), 10, 64)
+		if err != nil {
+			return fallback
+		}
+		return time.Unix(btime, 0).Add(time.Duration(ticks) * time.Second / clockTicks)
+	}
+	return fallback
+}
+
+// openFilesLimit returns the soft limit on open files from /proc/self/limits. The second
+// return is false if it is unknown or unlimited.
+func openFilesLimit() (float64, bool) {
+	f, err := os.Open("/proc/self/limits")
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if !strings.HasPrefix(line, "Max open files") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "Max open files"))
+		if len(fields) == 0 {
+			return 0, false
+		}
+		limit, err := strconv.ParseFloat(fields[0], 64)
+		return limit, err == nil
+	}
+	return 0, false
+}
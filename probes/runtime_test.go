@@ -0,0 +1,109 @@
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"math"
+	"os"
+	"path/filepath"
+	"runtime/metrics"
+	"testing"
+	"time"
+)
+
+func Test_RuntimeCollector(t *testing.T) {
+
+	Convey("When a RuntimeCollector samples the runtime, it returns metrics with thresholds", t, func() {
+		c := NewRuntimeCollector()
+		stats := make(map[string]health.Status)
+		for _, m := range c.Metrics() {
+			stats[m.Name] = m
+		}
+
+		So(stats, ShouldContainKey, "runtime.goroutines")
+		So(stats["runtime.goroutines"].Value, ShouldBeGreaterThan, 0)
+		So(stats["runtime.goroutines"].WarnOver, ShouldEqual, 10000)
+		So(stats, ShouldContainKey, "runtime.heap_in_use")
+		So(stats["runtime.heap_in_use"].Suffix, ShouldEqual, "B")
+		So(stats["runtime.heap_in_use"].Value, ShouldBeGreaterThan, 0)
+		So(stats, ShouldContainKey, "runtime.gc_pause_p50")
+		So(stats["runtime.gc_pause_p99"].BadOver, ShouldEqual, 500)
+		So(stats["runtime.uptime"].Suffix, ShouldEqual, "s")
+		So(stats["runtime.uptime"].Value, ShouldBeGreaterThanOrEqualTo, 0)
+
+		if _, err := os.Stat("/proc/self/fd"); err == nil {
+			So(stats, ShouldContainKey, "runtime.open_fds")
+			So(stats["runtime.open_fds"].Value, ShouldBeGreaterThan, 0)
+		}
+
+		Convey("and overridden thresholds and Prefix are used", func() {
+			c.Prefix = "self"
+			c.Goroutines = Thresholds{WarnOver: 0.5}
+
+			hc := health.NewCheck()
+			c.AddTo(&hc)
+			hc.Calculate()
+			So(len(hc.Metrics), ShouldEqual, len(stats))
+			So(hc.Metrics[0].Name, ShouldStartWith, "self.")
+			So(hc.OverallStatus, ShouldEqual, health.WARNING)
+		})
+
+		Convey("and Record stores them in a StatusRegistry", func() {
+			sr := health.NewStatusRegistry()
+			So(c.Record(sr), ShouldBeNil)
+
+			cat, err := sr.Category("runtime.goroutines")
+			So(err, ShouldBeNil)
+			So(cat, ShouldEqual, health.MetricCategory)
+			So(len(sr.Keys()), ShouldEqual, len(stats))
+		})
+	})
+
+	Convey("When percentile is given a histogram, it returns the upper bound of the bucket", t, func() {
+		h := &metrics.Float64Histogram{
+			Counts:  []uint64{5, 4, 1},
+			Buckets: []float64{0, 1, 2, math.Inf(1)},
+		}
+		So(percentile(h, 0.5), ShouldEqual, 1)
+		So(percentile(h, 0.9), ShouldEqual, 2)
+		So(percentile(h, 0.99), ShouldEqual, 2)
+
+		So(percentile(&metrics.Float64Histogram{Counts: []uint64{0}, Buckets: []float64{0, 1}}, 0.5), ShouldEqual, 0)
+	})
+
+	Convey("When the process start time is read from /proc, it is the boot time plus the start time", t, func() {
+		dir := t.TempDir()
+		defer func(selfStat, stat string) { procSelfStat, procStat = selfStat, stat }(procSelfStat, procStat)
+		procSelfStat = filepath.Join(dir, "self_stat")
+		procStat = filepath.Join(dir, "stat")
+		fallback := time.Unix(42, 0)
+
+		So(processStartTime(fallback), ShouldEqual, fallback)
+
+		So(os.WriteFile(procSelfStat, []byte("1234 (my (odd) cmd) S 1 1234 1234 0 -1 4194560 100 0 0 0 5 2 0 0 20 0 1 0 12345 1000 100 18446744073709551615\n"), 0600), ShouldBeNil)
+		So(processStartTime(fallback), ShouldEqual, fallback)
+
+		So(os.WriteFile(procStat, []byte("cpu  1 2 3 4\nbtime 1600000000\nprocesses 99\n"), 0600), ShouldBeNil)
+		So(processStartTime(fallback).Equal(time.Unix(1600000123, 450000000)), ShouldBeTrue)
+
+		So(os.WriteFile(procSelfStat, []byte("1234 (cmd) S 1 2 3\n"), 0600), ShouldBeNil)
+		So(processStartTime(fallback), ShouldEqual, fallback)
+	})
+
+	Convey("When GC pauses are sampled repeatedly, the percentiles are of the pauses since the last sample", t, func() {
+		c := NewRuntimeCollector()
+		buckets := []float64{0, 1, 2, math.Inf(1)}
+
+		h := c.pausesSince(&metrics.Float64Histogram{Counts: []uint64{9, 1, 0}, Buckets: buckets})
+		So(h.Counts, ShouldResemble, []uint64{9, 1, 0})
+		So(percentile(h, 0.99), ShouldEqual, 2)
+
+		h = c.pausesSince(&metrics.Float64Histogram{Counts: []uint64{9, 1, 3}, Buckets: buckets})
+		So(h.Counts, ShouldResemble, []uint64{0, 0, 3})
+		So(percentile(h, 0.5), ShouldEqual, 2)
+
+		h = c.pausesSince(&metrics.Float64Histogram{Counts: []uint64{9, 1, 3}, Buckets: buckets})
+		So(percentile(h, 0.99), ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,56 @@
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// TCPProbe is a health.Checker that dials a TCP address
+type TCPProbe struct {
+	// Address is the "host:port" to dial
+	Address string
+	// Latency is the thresholds for how long the dial takes
+	Latency Latency
+	// Dialer is used to dial Address. If nil, a zero net.Dialer is used
+	Dialer *net.Dialer
+
+	name string
+}
+
+// NewTCPProbe returns a TCPProbe with the specified name, that dials address
+func NewTCPProbe(name, address string) *TCPProbe {
+	return &TCPProbe{
+		Address: address,
+		name:    name,
+	}
+}
+
+// Name returns the name of the TCPProbe
+func (p *TCPProbe) Name() string {
+	return p.name
+}
+
+// Run dials Address, and closes the connection
+func (p *TCPProbe) Run(ctx context.Context) health.Status {
+	d := p.Dialer
+	if d == nil {
+		d = &net.Dialer{}
+	}
+
+	start := time.Now()
+	conn, err := d.DialContext(ctx, "tcp", p.Address)
+	latency := time.Since(start)
+	if err == nil {
+		conn.Close()
+	}
+
+	st := p.Latency.status(p.name, start, latency, err)
+	if err == nil {
+		st.Message = fmt.Sprintf("connected to %s", p.Address)
+	}
+	return st
+}
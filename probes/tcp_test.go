@@ -0,0 +1,54 @@
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func Test_TCPProbe(t *testing.T) {
+
+	Convey("When a TCPProbe dials a listening address, it is OK", t, func() {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		defer l.Close()
+
+		var p health.Checker = NewTCPProbe("listener", l.Addr().String())
+		So(p.Name(), ShouldEqual, "listener")
+
+		st := p.Run(context.Background())
+		So(st.Name, ShouldEqual, "listener")
+		So(st.Status, ShouldEqual, health.OK)
+		So(st.Error, ShouldBeEmpty)
+		So(st.Value, ShouldBeGreaterThan, 0)
+		So(st.Suffix, ShouldEqual, "ms")
+		So(st.TimeStamp, ShouldNotBeNil)
+		So(st.Message, ShouldContainSubstring, l.Addr().String())
+
+		Convey("and its latency thresholds apply", func() {
+			tp := NewTCPProbe("listener", l.Addr().String())
+			tp.Latency = Latency{WarnOver: time.Hour, BadOver: time.Nanosecond}
+
+			st := tp.Run(context.Background())
+			So(st.Status, ShouldEqual, health.CRITICAL)
+			So(st.WarnOver, ShouldEqual, 3600000)
+			So(st.BadOver, ShouldEqual, 0.000001)
+		})
+	})
+
+	Convey("When a TCPProbe dials a closed address, it is CRITICAL with the error", t, func() {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		addr := l.Addr().String()
+		l.Close()
+
+		st := NewTCPProbe("closed", addr).Run(context.Background())
+		So(st.Status, ShouldEqual, health.CRITICAL)
+		So(st.Error, ShouldContainSubstring, "refused")
+		So(st.WarnOver, ShouldBeNil)
+	})
+}
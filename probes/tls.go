@@ -0,0 +1,101 @@
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+)
+
+// TLSProbe is a health.Checker that completes a TLS handshake with a TCP address
+type TLSProbe struct {
+	// Address is the "host:port" to connect to
+	Address string
+	// Config is used for the handshake. If its ServerName is empty, the host of Address is used
+	Config *tls.Config
+	// Latency is the thresholds for how long the connection and handshake take
+	Latency Latency
+	// Dialer is used to dial Address. If nil, a zero net.Dialer is used
+	Dialer *net.Dialer
+
+	name string
+}
+
+// NewTLSProbe returns a TLSProbe with the specified name, that connects to address
+func NewTLSProbe(name, address string) *TLSProbe {
+	return &TLSProbe{
+		Address: address,
+		name:    name,
+	}
+}
+
+// Name returns the name of the TLSProbe
+func (p *TLSProbe) Name() string {
+	return p.name
+}
+
+// Run connects to Address, completes a TLS handshake, verifying the certificate chain and
+// name unless the Config says otherwise, and closes the connection
+func (p *TLSProbe) Run(ctx context.Context) health.Status {
+	start := time.Now()
+	state, err := p.handshake(ctx)
+	st := p.Latency.status(p.name, start, time.Since(start), err)
+	if err == nil {
+		st.Message = fmt.Sprintf("%s with %s", tlsVersion(state.Version), tls.CipherSuiteName(state.CipherSuite))
+	}
+	return st
+}
+
+// handshake connects to Address, and returns the state of the completed handshake
+func (p *TLSProbe) handshake(ctx context.Context) (tls.ConnectionState, error) {
+	conn, err := dialTLS(ctx, p.Dialer, p.Config, p.Address)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+	return conn.ConnectionState(), nil
+}
+
+// dialTLS connects to address and completes a TLS handshake. If the config has no ServerName,
+// the host of address is used.
+func dialTLS(ctx context.Context, d *net.Dialer, config *tls.Config, address string) (*tls.Conn, error) {
+	if config == nil {
+		config = &tls.Config{}
+	}
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		config = config.Clone()
+		config.ServerName = host
+	}
+
+	td := tls.Dialer{
+		NetDialer: d,
+		Config:    config,
+	}
+	conn, err := td.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return conn.(*tls.Conn), nil
+}
+
+// tlsVersion returns the name of the TLS version
+func tlsVersion(v uint16) string {
+	switch v {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	}
+	return fmt.Sprintf("TLS 0x%04x", v)
+}
@@ -0,0 +1,39 @@
+package probes
+
+import (
+	health "github.com/cognusion/go-health"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_TLSProbe(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "https://")
+
+	Convey("When a TLSProbe handshakes with a trusted server, it is OK", t, func() {
+		pool := x509.NewCertPool()
+		pool.AddCert(srv.Certificate())
+
+		p := NewTLSProbe("tls", addr)
+		p.Config = &tls.Config{RootCAs: pool}
+
+		st := p.Run(context.Background())
+		So(st.Status, ShouldEqual, health.OK)
+		So(st.Message, ShouldStartWith, "TLS 1.")
+		So(p.Config.ServerName, ShouldBeEmpty)
+	})
+
+	Convey("When a TLSProbe handshakes with an untrusted server, it is CRITICAL with the error", t, func() {
+		st := NewTLSProbe("tls", addr).Run(context.Background())
+		So(st.Status, ShouldEqual, health.CRITICAL)
+		So(st.Error, ShouldContainSubstring, "certificate")
+	})
+}
@@ -0,0 +1,231 @@
+package health
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// PrometheusContentType is the content type of the Prometheus text exposition format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultPrometheusNamespace is the prefix for all Prometheus metric names, unless otherwise specified
+const DefaultPrometheusNamespace = "gohealth"
+
+var (
+	// prometheusStates are the states enumerated for each status gauge
+	prometheusStates = []Severity{OK, WARNING, CRITICAL, UNKNOWN}
+
+	promLabelReplacer = strings.NewReplacer(
+		"\\", `\\`,
+		"\"", `\"`,
+		"\n", `\n`,
+	)
+
+	promHelpReplacer = strings.NewReplacer(
+		"\\", `\\`,
+		"\n", `\n`,
+	)
+)
+
+// PrometheusName returns a name that is safe to use as a Prometheus metric name,
+// replacing invalid characters as SafeLabel does, or with underscores
+func PrometheusName(name string) string {
+	name = strings.ReplaceAll(name, "%", "perc")
+
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// PrometheusExporter renders a Check in the Prometheus text exposition format
+type PrometheusExporter struct {
+	// Namespace prefixes every metric name. Defaults to DefaultPrometheusNamespace
+	Namespace string
+}
+
+// Write renders the provided Check to w. The Check should already be Calculate()d
+//
+// The overall status, and the status of each service and system, are rendered as
+// enumerated gauges with a "status" label. Services or systems with the same name are rendered
+// once, with the worst of their statuses. Each metric with a numeric Value is
+// rendered as its own gauge, with numeric WarnOver, BadOver, WarnUnder, and BadUnder
+// rendered as separate "_warn_over", "_bad_over", "_warn_under", and "_bad_under" gauges.
+// A metric whose name collides with a status gauge or an earlier metric is skipped, and a
+// threshold gauge whose name collides with a metric is omitted.
+func (p *PrometheusExporter) Write(w io.Writer, hc *Check) error {
+	ns := p.Namespace
+	if ns == "" {
+		ns = DefaultPrometheusNamespace
+	}
+	ns = PrometheusName(ns)
+
+	bw := bufio.NewWriter(w)
+
+	writeHeader(bw, ns+"_overall_status", "The overall status of the healthcheck")
+	for _, state := range prometheusStates {
+		fmt.Fprintf(bw, "%s_overall_status{status=%q} %d\n", ns, state, boolToInt(hc.OverallStatus.Canonical() == state))
+	}
+
+	writeStatusGauge(bw, ns+"_service_status", "The status of each service", hc.Services)
+	writeStatusGauge(bw, ns+"_system_status", "The status of each system", hc.Systems)
+
+	// Families must be unique, so metrics may not reuse the status gauges or each other, and
+	// thresholds may not reuse any metric
+	seen := map[string]bool{
+		ns + "_overall_status": true,
+		ns + "_service_status": true,
+		ns + "_system_status":  true,
+	}
+	type family struct {
+		name  string
+		value float64
+		m     *Status
+	}
+	var families []family
+	for i := range hc.Metrics {
+		m := &hc.Metrics[i]
+		v, ok := isNumericGimme(cast.ToString(m.Value))
+		if !ok {
+			continue
+		}
+
+		name := ns + "_" + PrometheusName(m.Name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		families = append(families, family{name, v, m})
+	}
+
+	for _, f := range families {
+		writeHeader(bw, f.name, fmt.Sprintf("The value of the %s metric", promHelpReplacer.Replace(f.m.Name)))
+		fmt.Fprintf(bw, "%s %s\n", f.name, formatFloat(f.value))
+
+		for _, t := range []struct {
+			suffix    string
+			threshold interface{}
+			help      string
+		}{
+			{"_warn_over", f.m.WarnOver, "The value over which %s is WARNING"},
+			{"_bad_over", f.m.BadOver, "The value over which %s is CRITICAL"},
+			{"_warn_under", f.m.WarnUnder, "The value under which %s is WARNING"},
+			{"_bad_under", f.m.BadUnder, "The value under which %s is CRITICAL"},
+		} {
+			tv, ok := isNumericGimme(cast.ToString(t.threshold))
+			if !ok || seen[f.name+t.suffix] {
+				continue
+			}
+			seen[f.name+t.suffix] = true
+			writeHeader(bw, f.name+t.suffix, fmt.Sprintf(t.help, promHelpReplacer.Replace(f.m.Name)))
+			fmt.Fprintf(bw, "%s%s %s\n", f.name, t.suffix, formatFloat(tv))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// PrometheusHandler is an http.Handler that serves a Check in the Prometheus text exposition format.
+// Unlike Handler, it always responds with a 200, as the status is conveyed in the metrics.
+type PrometheusHandler struct {
+	PrometheusExporter
+	// CheckFunc is called for every request, and must return a Check. The Check is
+	// Calculate()d before it is served
+	CheckFunc func() Check
+}
+
+// NewPrometheusHandler returns a PrometheusHandler that serves the Check returned by the provided function
+func NewPrometheusHandler(f func() Check) *PrometheusHandler {
+	return &PrometheusHandler{
+		CheckFunc: f,
+	}
+}
+
+// ServeHTTP handles GET and HEAD requests, responding with the Check as Prometheus metrics
+func (h *PrometheusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	hc := h.CheckFunc()
+	hc.Calculate()
+
+	var body bytes.Buffer
+	if err := h.Write(&body, &hc); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", PrometheusContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(body.Len()))
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Write(body.Bytes())
+}
+
+// writeStatusGauge writes an enumerated status gauge for each of the provided Statuses. Series
+// must be unique, so Statuses with the same name are written once, with the worst status.
+func writeStatusGauge(w io.Writer, name, help string, statuses []Status) {
+	if len(statuses) == 0 {
+		return
+	}
+
+	var labels []string
+	worst := make(map[string]Severity)
+	for _, s := range statuses {
+		label := promLabelReplacer.Replace(s.Name)
+		prev, ok := worst[label]
+		if !ok {
+			labels = append(labels, label)
+		}
+		worst[label] = Worst(prev, s.Status)
+	}
+
+	writeHeader(w, name, help)
+	for _, label := range labels {
+		status := worst[label].Canonical()
+		for _, state := range prometheusStates {
+			fmt.Fprintf(w, "%s{name=\"%s\",status=%q} %d\n", name, label, state, boolToInt(status == state))
+		}
+	}
+}
+
+// writeHeader writes the HELP and TYPE lines for a gauge
+func writeHeader(w io.Writer, name, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+}
+
+// formatFloat returns the shortest Prometheus-compatible representation of v
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
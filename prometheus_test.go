@@ -0,0 +1,104 @@
+package health
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_PrometheusName(t *testing.T) {
+
+	Convey("When a string is Prometheus-unsafe, it is rendered safe", t, func() {
+		So(PrometheusName("heap.used"), ShouldEqual, "heap_used")
+		So(PrometheusName("disk 100% full-ish"), ShouldEqual, "disk_100perc_full_ish")
+		So(PrometheusName("2xx:count"), ShouldEqual, "_2xx_count")
+	})
+}
+
+func Test_PrometheusExporter(t *testing.T) {
+
+	Convey("When a Check is exported as Prometheus text", t, func() {
+		hc := NewCheck()
+		hc.AddService(&Status{Name: "db", Status: UP})
+		hc.AddService(&Status{Name: "db", Status: DOWN})
+		hc.AddSystem(&Status{Name: "disk \"sda\"", Status: WARNING})
+		hc.AddMetric(&Status{Name: "heap.used", Value: 845643, WarnOver: 900000, BadOver: "1e6"})
+		hc.AddMetric(&Status{Name: "version", Value: "3.2.17"})
+		hc.Calculate()
+
+		var buf bytes.Buffer
+		p := PrometheusExporter{Namespace: "app"}
+		So(p.Write(&buf, &hc), ShouldBeNil)
+		out := buf.String()
+
+		Convey("the overall status is an enumerated gauge", func() {
+			So(out, ShouldContainSubstring, "# TYPE app_overall_status gauge\n")
+			So(out, ShouldContainSubstring, "app_overall_status{status=\"CRITICAL\"} 1\n")
+			So(out, ShouldContainSubstring, "app_overall_status{status=\"OK\"} 0\n")
+		})
+
+		Convey("services and systems are enumerated gauges, with aliases folded, names escaped, and the worst of duplicates", func() {
+			So(out, ShouldContainSubstring, "app_service_status{name=\"db\",status=\"CRITICAL\"} 1\n")
+			So(out, ShouldContainSubstring, "app_service_status{name=\"db\",status=\"OK\"} 0\n")
+			So(strings.Count(out, "app_service_status{name=\"db\",status=\"OK\"}"), ShouldEqual, 1)
+			So(out, ShouldContainSubstring, "app_system_status{name=\"disk \\\"sda\\\"\",status=\"WARNING\"} 1\n")
+		})
+
+		Convey("numeric metrics and their thresholds are gauges, and others are skipped", func() {
+			So(out, ShouldContainSubstring, "# TYPE app_heap_used gauge\napp_heap_used 845643\n")
+			So(out, ShouldContainSubstring, "app_heap_used_warn_over 900000\n")
+			So(out, ShouldContainSubstring, "app_heap_used_bad_over 1e+06\n")
+			So(out, ShouldNotContainSubstring, "app_version")
+		})
+	})
+
+	Convey("When metric names collide with the status gauges, or each other's thresholds, the duplicates are skipped", t, func() {
+		hc := NewCheck()
+		hc.AddService(&Status{Name: "db", Status: OK})
+		hc.AddMetric(&Status{Name: "service.status", Value: 1})
+		hc.AddMetric(&Status{Name: "foo", Value: 2, WarnOver: 10})
+		hc.AddMetric(&Status{Name: "foo_warn_over", Value: 3})
+		hc.AddMetric(&Status{Name: "foo:warn_over", Value: 4})
+		hc.Calculate()
+
+		var buf bytes.Buffer
+		p := PrometheusExporter{Namespace: "app"}
+		So(p.Write(&buf, &hc), ShouldBeNil)
+		out := buf.String()
+
+		So(strings.Count(out, "# TYPE app_service_status gauge\n"), ShouldEqual, 1)
+		So(out, ShouldNotContainSubstring, "app_service_status 1\n")
+		So(out, ShouldContainSubstring, "app_foo 2\n")
+		So(strings.Count(out, "# TYPE app_foo_warn_over gauge\n"), ShouldEqual, 1)
+		So(out, ShouldContainSubstring, "app_foo_warn_over 3\n")
+		So(out, ShouldNotContainSubstring, "app_foo_warn_over 10\n")
+		So(out, ShouldNotContainSubstring, "app_foo_warn_over 4\n")
+	})
+}
+
+func Test_PrometheusHandler(t *testing.T) {
+
+	Convey("When a PrometheusHandler serves a critical StatusRegistry, it responds with a 200 and metrics", t, func() {
+		sr := NewStatusRegistry()
+		sr.Add("db", CRITICAL, nil, nil)
+
+		h := NewPrometheusHandler(sr.Snapshot)
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		So(rr.Code, ShouldEqual, http.StatusOK)
+		So(rr.Header().Get("Content-Type"), ShouldEqual, PrometheusContentType)
+		So(rr.Body.String(), ShouldContainSubstring, "gohealth_service_status{name=\"db\",status=\"CRITICAL\"} 1\n")
+		So(rr.Header().Get("Content-Length"), ShouldEqual, strconv.Itoa(rr.Body.Len()))
+
+		rr = httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodHead, "/metrics", nil))
+		So(rr.Code, ShouldEqual, http.StatusOK)
+		So(rr.Body.Len(), ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,217 @@
+package health
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// Range is a Nagios threshold range, as described in the Nagios Plugin Development Guidelines.
+// "10" alerts outside of 0 to 10, "10:" alerts below 10, "~:10" alerts above 10, "10:20" alerts
+// outside of 10 to 20, and "@10:20" alerts inside of 10 to 20, inclusive.
+type Range struct {
+	// Start is the lower bound of the Range, and may be -Inf
+	Start float64
+	// End is the upper bound of the Range, and may be +Inf
+	End float64
+	// Inside is true if values within the Range alert, instead of values outside of it
+	Inside bool
+}
+
+// ParseRange returns the Range represented by the string, or an error. Infinite bounds are
+// written as "~" or omitted, so NaN and Inf are not accepted.
+func ParseRange(s string) (*Range, error) {
+	var r = Range{
+		End: math.Inf(1),
+	}
+
+	rs := strings.TrimSpace(s)
+	if strings.HasPrefix(rs, "@") {
+		r.Inside = true
+		rs = rs[1:]
+	}
+	if rs == "" {
+		return nil, fmt.Errorf("empty range '%s'", s)
+	}
+
+	start, end, hasColon := strings.Cut(rs, ":")
+	if !hasColon {
+		// A lone number is the end, with a start of 0
+		end, start = start, "0"
+	}
+
+	switch start {
+	case "":
+		r.Start = 0
+	case "~":
+		r.Start = math.Inf(-1)
+	default:
+		v, ok := isNumericGimme(start)
+		if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("invalid range start in '%s'", s)
+		}
+		r.Start = v
+	}
+
+	if end != "" {
+		v, ok := isNumericGimme(end)
+		if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("invalid range end in '%s'", s)
+		}
+		r.End = v
+	}
+
+	if r.Start > r.End {
+		return nil, fmt.Errorf("range start is greater than end in '%s'", s)
+	}
+	return &r, nil
+}
+
+// Alert returns true if the value should generate an alert
+func (r *Range) Alert(v float64) bool {
+	inside := v >= r.Start && v <= r.End
+	if r.Inside {
+		return inside
+	}
+	return !inside
+}
+
+// String returns the Nagios representation of the Range
+func (r *Range) String() string {
+	var b strings.Builder
+	if r.Inside {
+		b.WriteString("@")
+	}
+	if math.IsInf(r.Start, -1) {
+		b.WriteString("~:")
+	} else if r.Start != 0 || math.IsInf(r.End, 1) {
+		b.WriteString(cast.ToString(r.Start))
+		b.WriteString(":")
+	}
+	if !math.IsInf(r.End, 1) {
+		b.WriteString(cast.ToString(r.End))
+	}
+	return b.String()
+}
+
+// isRange returns true if the threshold is a string in Nagios range syntax, rather than a plain number
+func isRange(threshold interface{}) bool {
+	s, ok := threshold.(string)
+	return ok && strings.ContainsAny(s, ":@~")
+}
+
+// thresholdAlert returns true if v breaches the threshold. Numeric thresholds are breached when v
+// is over them, or under them if under is true. Thresholds in Nagios range syntax are breached
+// according to Range.Alert, regardless of under.
+func thresholdAlert(threshold interface{}, v float64, under bool) bool {
+	if isRange(threshold) {
+		r, err := ParseRange(threshold.(string))
+		return err == nil && r.Alert(v)
+	}
+
+	t, ok := isNumericGimme(cast.ToString(threshold))
+	if !ok {
+		return false
+	}
+	if under {
+		return v < t
+	}
+	return v > t
+}
+
+// thresholdStatus returns CRITICAL if v breaches badOver or badUnder, WARNING if v
+// breaches warnOver or warnUnder, or an empty Severity
+func thresholdStatus(v float64, warnOver, badOver, warnUnder, badUnder interface{}) Severity {
+	switch {
+	case thresholdAlert(badOver, v, false), thresholdAlert(badUnder, v, true):
+		return CRITICAL
+	case thresholdAlert(warnOver, v, false), thresholdAlert(warnUnder, v, true):
+		return WARNING
+	}
+	return ""
+}
+
+// perfThreshold returns the Nagios Performance Data representation of the over and under
+// thresholds. Plain thresholds are passed through as-is. If either is a range, both are
+// merged into the one range that alerts when either would. If no single range can (such
+// as an "@" range with another threshold, or an under greater than the over, which always
+// alerts), an empty string is returned rather than a threshold that alerts differently.
+func perfThreshold(over, under interface{}) string {
+	if !isRange(over) && !isRange(under) {
+		o := cast.ToString(over)
+		u := cast.ToString(under)
+		if u == "" {
+			return o
+		}
+		if fo, err := cast.ToFloat64E(over); err == nil && o != "" {
+			if fu, err := cast.ToFloat64E(under); err == nil && fu > fo {
+				// Always alerts, which no range can say, as in the merge below
+				return ""
+			}
+		}
+		return fmt.Sprintf("%s:%s", u, o)
+	}
+
+	var (
+		ranges []*Range
+		lone   string
+	)
+	for _, t := range []struct {
+		threshold interface{}
+		under     bool
+	}{
+		{over, false},
+		{under, true},
+	} {
+		if r := thresholdRange(t.threshold, t.under); r != nil {
+			ranges = append(ranges, r)
+			lone = r.String()
+			if isRange(t.threshold) {
+				lone = cast.ToString(t.threshold)
+			}
+		}
+	}
+
+	switch len(ranges) {
+	case 0:
+		return ""
+	case 1:
+		// Ranges are passed through as-is
+		return lone
+	}
+
+	// Both alert outside of their Range, so together they alert outside of the intersection
+	if ranges[0].Inside || ranges[1].Inside {
+		return ""
+	}
+	merged := Range{
+		Start: math.Max(ranges[0].Start, ranges[1].Start),
+		End:   math.Min(ranges[0].End, ranges[1].End),
+	}
+	if merged.Start > merged.End {
+		return ""
+	}
+	return merged.String()
+}
+
+// thresholdRange returns the Range that alerts as the threshold does, or nil if it never alerts
+func thresholdRange(threshold interface{}, under bool) *Range {
+	if isRange(threshold) {
+		r, err := ParseRange(threshold.(string))
+		if err != nil {
+			return nil
+		}
+		return r
+	}
+
+	t, ok := isNumericGimme(cast.ToString(threshold))
+	if !ok {
+		return nil
+	}
+	if under {
+		return &Range{Start: t, End: math.Inf(1)}
+	}
+	return &Range{Start: math.Inf(-1), End: t}
+}
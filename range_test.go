@@ -0,0 +1,138 @@
+package health
+
+import (
+	nagios "github.com/cognusion/go-nagios-checks"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"testing"
+)
+
+func Test_ParseRange(t *testing.T) {
+
+	Convey("When Nagios ranges are parsed, they alert correctly", t, func() {
+		for _, tc := range []struct {
+			r      string
+			str    string
+			alerts []float64
+			quiet  []float64
+		}{
+			{"10", "10", []float64{-1, 11}, []float64{0, 5, 10}},
+			{"10:", "10:", []float64{9.9, -5}, []float64{10, 1000}},
+			{"~:10", "~:10", []float64{10.1}, []float64{-1000, 10}},
+			{"10:20", "10:20", []float64{9, 21}, []float64{10, 15, 20}},
+			{"@10:20", "@10:20", []float64{10, 15, 20}, []float64{9, 21}},
+			{"@5:8", "@5:8", []float64{5, 8}, []float64{4.9, 8.1}},
+			{"-5:-1", "-5:-1", []float64{0, -6}, []float64{-3}},
+		} {
+			r, err := ParseRange(tc.r)
+			So(err, ShouldBeNil)
+			So(r.String(), ShouldEqual, tc.str)
+			for _, v := range tc.alerts {
+				So(r.Alert(v), ShouldBeTrue)
+			}
+			for _, v := range tc.quiet {
+				So(r.Alert(v), ShouldBeFalse)
+			}
+		}
+	})
+
+	Convey("When invalid Nagios ranges are parsed, errors are returned", t, func() {
+		for _, r := range []string{"", "@", "abc", "1:abc", "20:10", "~:~", "NaN", "0:NaN", "Inf", "-Inf:0", "1:+Inf"} {
+			_, err := ParseRange(r)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func Test_LowSideThresholds(t *testing.T) {
+
+	Convey("When a range and a plain threshold are rendered as Performance Data, they are merged", t, func() {
+		So(perfThreshold("10:20", 5), ShouldEqual, "10:20")
+		So(perfThreshold("10:20", 15), ShouldEqual, "15:20")
+		So(perfThreshold(18, "10:"), ShouldEqual, "10:18")
+		So(perfThreshold("~:90", 10), ShouldEqual, "10:90")
+		So(perfThreshold("10:20", nil), ShouldEqual, "10:20")
+		So(perfThreshold(nil, "@0:10"), ShouldEqual, "@0:10")
+		So(perfThreshold(90, 10), ShouldEqual, "10:90")
+		So(perfThreshold("1:abc", 10), ShouldEqual, "10:")
+
+		Convey("unless no single range alerts the same way", func() {
+			So(perfThreshold("@10:20", 5), ShouldEqual, "")
+			So(perfThreshold("10:20", 30), ShouldEqual, "")
+			So(perfThreshold(10, 20), ShouldEqual, "")
+			So(perfThreshold("10", "20.5"), ShouldEqual, "")
+		})
+
+		Convey("and a plain range is not inverted", func() {
+			So(perfThreshold(20, 20), ShouldEqual, "20:20")
+			So(perfThreshold(nil, 20), ShouldEqual, "20:")
+		})
+	})
+
+	Convey("When a Check has metrics with low-side thresholds, Calculate honors them", t, func() {
+		hc := NewCheck()
+		hc.AddMetric(&Status{Name: "free", Value: 15, WarnUnder: 20, BadUnder: 10})
+		hc.Calculate()
+		So(hc.OverallStatus, ShouldEqual, WARNING)
+
+		hc.AddMetric(&Status{Name: "hits", Value: "0.1", BadUnder: 0.5})
+		hc.Calculate()
+		So(hc.OverallStatus, ShouldEqual, CRITICAL)
+	})
+
+	Convey("When a Check has metrics with range thresholds, Calculate honors them", t, func() {
+		hc := NewCheck()
+		hc.AddMetric(&Status{Name: "temp", Value: 15, WarnOver: "10:20", BadOver: "@30:40"})
+		hc.Calculate()
+		So(hc.OverallStatus, ShouldEqual, OK)
+
+		hc.Metrics[0].Value = 25
+		hc.Calculate()
+		So(hc.OverallStatus, ShouldEqual, WARNING)
+
+		hc.Metrics[0].Value = 35
+		hc.Calculate()
+		So(hc.OverallStatus, ShouldEqual, CRITICAL)
+	})
+
+	Convey("When low-side thresholds are in JSON, they are parsed and rendered", t, func() {
+		hc, err := NewCheckfromJSON([]byte(`{"overallStatus":"OK","metrics":[
+			{"name":"free","value":5,"warnUnder":20,"badUnder":10,"warnOver":90},
+			{"name":"idle","value":5,"warnOver":"@0:10"}
+		]}`))
+		So(err, ShouldBeNil)
+		So(hc.Validate(), ShouldBeNil)
+		So(hc.OverallStatus, ShouldEqual, CRITICAL)
+		So(hc.Metrics[0].WarnUnder, ShouldEqual, 20)
+		So(hc.Metrics[0].BadUnder, ShouldEqual, 10)
+		So(hc.Metrics[0].MetricString(), ShouldEqual, "'free'=5;20:90;10:;;")
+		So(hc.Metrics[1].MetricString(), ShouldEqual, "'idle'=5;@0:10;;;")
+		So(hc.JSON(), ShouldContainSubstring, `"warnUnder":20,"badUnder":10`)
+	})
+
+	Convey("When a metrics document has low-side thresholds, Metrics honors them", t, func() {
+		metrics := []interface{}{
+			map[string]interface{}{
+				"name":      "free",
+				"value":     15,
+				"warnUnder": 20,
+				"badUnder":  10,
+			},
+		}
+		var n nagios.Nagios
+		Metrics(&n, metrics, false)
+		So(n.Status(), ShouldEqual, nagios.WARNING)
+		So(n.Metrics, ShouldResemble, []string{"'free'=15;20:;10:;;"})
+
+		metrics = append(metrics, map[string]interface{}{
+			"name":     "conns",
+			"value":    7,
+			"badOver":  "@5:8",
+			"minValue": 0,
+		})
+		n = nagios.Nagios{}
+		Metrics(&n, metrics, false)
+		So(n.Status(), ShouldEqual, nagios.CRITICAL)
+		So(n.Metrics[1], ShouldEqual, "'conns'=7;;@5:8;0;")
+	})
+}
@@ -0,0 +1,245 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Checker is an interface for anything that can be Run to produce a Status
+type Checker interface {
+	// Name returns the name the resulting Status will be stored under
+	Name() string
+	// Run executes the check, returning its Status. Implementations should honor
+	// the cancellation of the provided context
+	Run(ctx context.Context) Status
+}
+
+// funcChecker is a Checker wrapping a simple function
+type funcChecker struct {
+	name string
+	f    func(ctx context.Context) Status
+}
+
+// NewChecker returns a Checker with the specified name, which calls the provided function when Run
+func NewChecker(name string, f func(ctx context.Context) Status) Checker {
+	return &funcChecker{
+		name: name,
+		f:    f,
+	}
+}
+
+// Name returns the name of the Checker
+func (f *funcChecker) Name() string {
+	return f.name
+}
+
+// Run calls the wrapped function
+func (f *funcChecker) Run(ctx context.Context) Status {
+	return f.f(ctx)
+}
+
+// Schedule describes how often a Checker is Run by a Scheduler
+type Schedule struct {
+	// Interval is the amount of time between runs, and is required
+	Interval time.Duration
+	// Timeout is the maximum amount of time a single run may take before it is
+	// considered CRITICAL. Defaults to Interval
+	Timeout time.Duration
+	// Jitter is the maximum amount of random time added to each Interval, to prevent
+	// many Checkers from running in lockstep
+	Jitter time.Duration
+	// Category is the StatusRegistry Category results are stored in. Defaults to
+	// ServiceCategory
+	Category Category
+}
+
+// scheduled is a Checker and its Schedule
+type scheduled struct {
+	checker  Checker
+	schedule Schedule
+}
+
+// Scheduler periodically Runs Checkers, storing their Status in a StatusRegistry
+type Scheduler struct {
+	registry *StatusRegistry
+	lock     sync.Mutex
+	checks   []*scheduled
+	stop     chan struct{}
+	// wg tracks the goroutines of the current Start, so a Stop waits only on its own
+	wg *sync.WaitGroup
+}
+
+// NewScheduler returns a Scheduler that stores results in the provided StatusRegistry
+func NewScheduler(sr *StatusRegistry) *Scheduler {
+	return &Scheduler{
+		registry: sr,
+	}
+}
+
+// Add a Checker to be Run on the provided Schedule. If the Scheduler has already been
+// Started, the Checker begins running immediately.
+func (s *Scheduler) Add(c Checker, sched Schedule) error {
+	if sched.Interval <= 0 {
+		return fmt.Errorf("interval for %s must be positive, not %s", c.Name(), sched.Interval)
+	}
+	if sched.Timeout <= 0 {
+		sched.Timeout = sched.Interval
+	}
+
+	sc := &scheduled{
+		checker:  c,
+		schedule: sched,
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.checks = append(s.checks, sc)
+	if s.stop != nil {
+		s.loop(sc, s.stop, s.wg)
+	}
+	return nil
+}
+
+// Start Running all of the added Checkers on their Schedules. Calling Start on a
+// running Scheduler has no effect.
+func (s *Scheduler) Start() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.wg = &sync.WaitGroup{}
+	for _, sc := range s.checks {
+		s.loop(sc, s.stop, s.wg)
+	}
+}
+
+// Stop Running Checkers, waiting for any in-progress runs to complete, including Checkers
+// that ignore the cancellation of their context. Stop does not return until they do, so a
+// Checker that never returns blocks Stop forever. A stopped Scheduler may be Started again.
+func (s *Scheduler) Stop() {
+	s.lock.Lock()
+	if s.stop == nil {
+		s.lock.Unlock()
+		return
+	}
+	close(s.stop)
+	wg := s.wg
+	s.stop = nil
+	s.wg = nil
+	s.lock.Unlock()
+
+	wg.Wait()
+}
+
+// RunAll Runs every added Checker once, synchronously, storing the results
+func (s *Scheduler) RunAll(ctx context.Context) {
+	s.lock.Lock()
+	checks := make([]*scheduled, len(s.checks))
+	copy(checks, s.checks)
+	s.lock.Unlock()
+
+	var wg sync.WaitGroup
+	for _, sc := range checks {
+		wg.Add(1)
+		go func(sc *scheduled) {
+			defer wg.Done()
+			s.run(ctx, sc, nil)
+		}(sc)
+	}
+	wg.Wait()
+}
+
+// loop starts a goroutine, tracked by wg, that Runs the scheduled Checker until stop is
+// closed. Must be called with s.lock held.
+func (s *Scheduler) loop(sc *scheduled, stop chan struct{}, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		t := time.NewTimer(jitter(sc.schedule.Jitter))
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				select {
+				case <-stop:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+			s.run(ctx, sc, wg)
+			cancel()
+
+			t.Reset(sc.schedule.Interval + jitter(sc.schedule.Jitter))
+		}
+	}()
+}
+
+// run executes a single scheduled Checker, with a timeout, and stores the result. If wg is
+// not nil, it tracks the Checker until it returns, even after the timeout.
+func (s *Scheduler) run(ctx context.Context, sc *scheduled, wg *sync.WaitGroup) {
+	ctx, cancel := context.WithTimeout(ctx, sc.schedule.Timeout)
+	defer cancel()
+
+	name := sc.checker.Name()
+	start := time.Now()
+	result := make(chan Status, 1)
+	if wg != nil {
+		wg.Add(1)
+	}
+	go func() {
+		if wg != nil {
+			defer wg.Done()
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				result <- Status{
+					Status: CRITICAL,
+					Error:  fmt.Sprintf("panic: %v", r),
+				}
+			}
+		}()
+		result <- sc.checker.Run(ctx)
+	}()
+
+	var stat Status
+	select {
+	case stat = <-result:
+	case <-ctx.Done():
+		stat = Status{
+			Status: CRITICAL,
+			Error:  fmt.Sprintf("check did not complete: %v", ctx.Err()),
+		}
+	}
+
+	if stat.TimeStamp == nil {
+		stat.TimeStamp = &start
+	}
+	if stat.TimeOut == nil {
+		// Allow for one missed run before considering the Status stale
+		to := 2*sc.schedule.Interval + sc.schedule.Jitter
+		stat.TimeOut = &to
+	}
+
+	s.registry.set(sc.schedule.Category, name, stat)
+}
+
+// jitter returns a random duration in [0,max)
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
@@ -0,0 +1,163 @@
+package health
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_SchedulerRunAll(t *testing.T) {
+
+	Convey("When a Scheduler has Checkers added, and RunAll is called", t, func() {
+		sr := NewStatusRegistry()
+		s := NewScheduler(sr)
+
+		So(s.Add(NewChecker("good", func(ctx context.Context) Status {
+			return Status{Status: OK, Value: 1}
+		}), Schedule{Interval: time.Minute}), ShouldBeNil)
+
+		So(s.Add(NewChecker("panicky", func(ctx context.Context) Status {
+			panic("oh no")
+		}), Schedule{Interval: time.Minute}), ShouldBeNil)
+
+		So(s.Add(NewChecker("slow", func(ctx context.Context) Status {
+			<-ctx.Done()
+			time.Sleep(50 * time.Millisecond)
+			return Status{Status: OK}
+		}), Schedule{Interval: time.Minute, Timeout: 10 * time.Millisecond}), ShouldBeNil)
+
+		s.RunAll(context.Background())
+
+		Convey("a good Checker is stored with TimeStamp and TimeOut filled in", func() {
+			stat, err := sr.Get("good")
+			So(err, ShouldBeNil)
+			So(stat.Name, ShouldEqual, "good")
+			So(stat.Status, ShouldEqual, OK)
+			So(stat.TimeStamp, ShouldNotBeNil)
+			So(stat.TimeOut, ShouldNotBeNil)
+			So(*stat.TimeOut, ShouldEqual, 2*time.Minute)
+		})
+
+		Convey("a panicking Checker is stored as CRITICAL with the panic message", func() {
+			stat, err := sr.Get("panicky")
+			So(err, ShouldBeNil)
+			So(stat.Status, ShouldEqual, CRITICAL)
+			So(stat.Error, ShouldEqual, "panic: oh no")
+		})
+
+		Convey("a Checker that times out is stored as CRITICAL", func() {
+			stat, err := sr.Get("slow")
+			So(err, ShouldBeNil)
+			So(stat.Status, ShouldEqual, CRITICAL)
+			So(stat.Error, ShouldContainSubstring, "deadline exceeded")
+		})
+	})
+
+	Convey("When a Checker is added with a non-positive Interval, an error is returned", t, func() {
+		s := NewScheduler(NewStatusRegistry())
+		err := s.Add(NewChecker("never", func(ctx context.Context) Status {
+			return Status{Status: OK}
+		}), Schedule{})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func Test_SchedulerStartStop(t *testing.T) {
+
+	Convey("When a Scheduler is Started, Checkers are run periodically until Stopped", t, func() {
+		var runs int32
+		ran := make(chan struct{}, 1)
+		sr := NewStatusRegistry()
+		s := NewScheduler(sr)
+
+		So(s.Add(NewChecker("counter", func(ctx context.Context) Status {
+			atomic.AddInt32(&runs, 1)
+			select {
+			case ran <- struct{}{}:
+			default:
+			}
+			return Status{Status: OK}
+		}), Schedule{Interval: time.Millisecond, Jitter: time.Millisecond}), ShouldBeNil)
+
+		s.Start()
+		s.Start() // no-op
+		for i := 0; i < 3; i++ {
+			select {
+			case <-ran:
+			case <-time.After(time.Second):
+				So("timed out", ShouldBeEmpty)
+			}
+		}
+		s.Stop()
+		s.Stop() // no-op
+
+		// Stop waited for the loops, so no more runs can start
+		stopped := atomic.LoadInt32(&runs)
+		So(stopped, ShouldBeGreaterThanOrEqualTo, 3)
+
+		_, err := sr.Get("counter")
+		So(err, ShouldBeNil)
+		So(atomic.LoadInt32(&runs), ShouldEqual, stopped)
+	})
+
+	Convey("When a Scheduler is Stopped, it waits for Checkers that ignore their context", t, func() {
+		var returned int32
+		started := make(chan struct{})
+		release := make(chan struct{})
+		s := NewScheduler(NewStatusRegistry())
+
+		So(s.Add(NewChecker("stubborn", func(ctx context.Context) Status {
+			close(started)
+			<-release
+			atomic.StoreInt32(&returned, 1)
+			return Status{Status: OK}
+		}), Schedule{Interval: time.Hour, Timeout: time.Millisecond}), ShouldBeNil)
+
+		s.Start()
+		<-started
+
+		stopped := make(chan struct{})
+		go func() {
+			s.Stop()
+			close(stopped)
+		}()
+
+		// The run has long since timed out, but Stop is still waiting for the Checker
+		select {
+		case <-stopped:
+			So("Stop returned before the Checker", ShouldBeEmpty)
+		case <-time.After(50 * time.Millisecond):
+		}
+		So(atomic.LoadInt32(&returned), ShouldEqual, 0)
+
+		close(release)
+		<-stopped
+		So(atomic.LoadInt32(&returned), ShouldEqual, 1)
+	})
+
+	Convey("When a Scheduler is Started and Stopped concurrently, it does not misuse its WaitGroup", t, func() {
+		s := NewScheduler(NewStatusRegistry())
+		So(s.Add(NewChecker("quick", func(ctx context.Context) Status {
+			return Status{Status: OK}
+		}), Schedule{Interval: time.Millisecond}), ShouldBeNil)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					s.Start()
+					s.Stop()
+				}
+			}()
+		}
+		wg.Wait()
+		s.Stop()
+		So(s.stop, ShouldBeNil)
+	})
+}
@@ -1,6 +1,6 @@
 package health
 
-// SchemaJSON was generated from schema.json at Sun Apr 17 12:23:48 PM EDT 2022
+// SchemaJSON was generated from schema.json at Sun Oct 18 08:45:40 UTC 2026
 var SchemaJSON = []byte(`
 {
 	"$schema": "http://json-schema.org/draft-07/schema#",
@@ -77,9 +77,27 @@ var SchemaJSON = []byte(`
           "description": "The number of milliseconds allowed to lapse between 'timeStamp' and 'now' before the metric is declared stale",
           "type": ["integer", "null"]
         },
+        "stale": {
+          "description": "Whether the 'timeStamp' has drifted beyond the 'timeout', making the status out of date",
+          "type": ["boolean", "null"]
+        },
+        "importance": {
+          "description": "How much the status matters to the overall status: required (the default), degraded (at worst WARNING), or informational (never)",
+          "type": ["string", "null"],
+          "enum": [
+            "required",
+            "degraded",
+            "informational",
+            null
+          ]
+        },
         "message": {
             "type": ["string", "null"],
           "description": "A message explaining why the status is what it is (often exception message)"
+        },
+        "error": {
+          "type": ["string", "null"],
+          "description": "The error that caused the status, if any"
         }
       }
     },
@@ -96,12 +114,21 @@ var SchemaJSON = []byte(`
       "additionalProperties": true,
       "properties": {
         "value": {
-          "description": "The current value for this metric",
-          "type": "number"
+          "description": "The current value for this metric, a geopoint, or an object of named child values that inherit the rest of this metric",
+          "type": ["number", "object"],
+          "anyOf": [
+            { "type": "number" },
+            { "$ref": "#/definitions/geopoint" },
+            { "not": { "anyOf": [{ "required": ["lat"] }, { "required": ["lon"] }] } }
+          ]
         },
         "expectedValue": {
-          "description": "The declared value that was expected for this metric",
-          "type": ["number", "null"]
+          "description": "The declared value that was expected for this metric. If a geopoint, the thresholds are the distance from it in kilometers",
+          "type": ["number", "object", "null"],
+          "anyOf": [
+            { "type": ["number", "null"] },
+            { "$ref": "#/definitions/geopoint" }
+          ]
         },
         "minValue": {
           "description": "The declared minimum value that for this metric (graph floor)",
@@ -111,13 +138,47 @@ var SchemaJSON = []byte(`
           "description": "The declared maximum value that for this metric (graph ceiling)",
           "type": ["number", "null"]
         },
+        "suffix": {
+          "description": "The unit of measure for this metric, e.g. s, ms, us, %, B, KB, MB, GB, TB, or c",
+          "type": ["string", "null"]
+        },
         "warnOver": {
-          "description": "The value at which exceeding values generate WARNING status (graph yellow-line)",
-          "type": ["number", "null"]
+          "description": "The value at which exceeding values generate WARNING status (graph yellow-line), or a Nagios range",
+          "type": ["number", "string", "null"]
         },
         "badOver": {
-          "description": "The value at which exceeding values generate CRITICAL status (graph red-line)",
-          "type": ["number", "null"]
+          "description": "The value at which exceeding values generate CRITICAL status (graph red-line), or a Nagios range",
+          "type": ["number", "string", "null"]
+        },
+        "warnUnder": {
+          "description": "The value at which lesser values generate WARNING status, or a Nagios range",
+          "type": ["number", "string", "null"]
+        },
+        "badUnder": {
+          "description": "The value at which lesser values generate CRITICAL status, or a Nagios range",
+          "type": ["number", "string", "null"]
+        }
+      }
+    },
+    "geopoint": {
+      "type": "object",
+      "required": [
+        "lat",
+        "lon"
+      ],
+      "additionalProperties": true,
+      "properties": {
+        "lat": {
+          "description": "The latitude, in decimal degrees",
+          "type": "number",
+          "minimum": -90,
+          "maximum": 90
+        },
+        "lon": {
+          "description": "The longitude, in decimal degrees",
+          "type": "number",
+          "minimum": -180,
+          "maximum": 180
         }
       }
     },
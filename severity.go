@@ -0,0 +1,130 @@
+package health
+
+import (
+	"fmt"
+	"strings"
+
+	nagios "github.com/cognusion/go-nagios-checks"
+)
+
+// Severity is the status of a Check, Service, System, or Metric, one of the Status constants.
+// Severities are plain strings on the wire, but are ordered by how severe they are, with
+// aliases folded together: OK and UP; BAD, ERROR, DOWN, and CRITICAL.
+type Severity string
+
+// severityAliases maps lowercased names onto the Status constants
+var severityAliases = map[string]Severity{
+	"ok":       OK,
+	"up":       UP,
+	"pass":     OK,
+	"warning":  WARNING,
+	"warn":     WARNING,
+	"bad":      BAD,
+	"error":    ERROR,
+	"down":     DOWN,
+	"critical": CRITICAL,
+	"fail":     CRITICAL,
+	"unknown":  UNKNOWN,
+}
+
+// ParseSeverity returns the Severity named by the string, case-insensitively. Aliases are
+// preserved, so "up" parses to UP, not OK. An empty string parses to an empty Severity,
+// meaning "not declared". Anything else unrecognized is UNKNOWN, with an error.
+func ParseSeverity(s string) (Severity, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+	if sev, ok := severityAliases[strings.ToLower(s)]; ok {
+		return sev, nil
+	}
+	return UNKNOWN, fmt.Errorf("unrecognized severity '%s'", s)
+}
+
+// Canonical returns the Severity with aliases folded together, as one of OK, WARNING,
+// CRITICAL, or UNKNOWN. Empty and unrecognized Severities are UNKNOWN.
+func (s Severity) Canonical() Severity {
+	sev, _ := ParseSeverity(string(s))
+	switch sev {
+	case OK, UP:
+		return OK
+	case WARNING:
+		return WARNING
+	case BAD, ERROR, DOWN, CRITICAL:
+		return CRITICAL
+	}
+	return UNKNOWN
+}
+
+// Rank returns a number representing how severe the Severity is, where higher is more
+// severe: OK, UNKNOWN, WARNING, then CRITICAL
+func (s Severity) Rank() int {
+	switch s.Canonical() {
+	case OK:
+		return 0
+	case WARNING:
+		return 2
+	case CRITICAL:
+		return 3
+	}
+	return 1
+}
+
+// IsOK returns true if the Severity is OK or UP
+func (s Severity) IsOK() bool {
+	return s.Canonical() == OK
+}
+
+// WorseThan returns true if the Severity is more severe than the other
+func (s Severity) WorseThan(other Severity) bool {
+	return s.Rank() > other.Rank()
+}
+
+// NagiosCode returns the Nagios exit status corresponding to the Severity
+func (s Severity) NagiosCode() int {
+	switch s.Canonical() {
+	case OK:
+		return nagios.OK
+	case WARNING:
+		return nagios.WARNING
+	case CRITICAL:
+		return nagios.CRITICAL
+	}
+	return nagios.UNKNOWN
+}
+
+// MarshalText returns the Severity as-is, so the wire format is unchanged
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s), nil
+}
+
+// UnmarshalText parses the Severity leniently, as ParseSeverity does, with unrecognized
+// text becoming UNKNOWN rather than an error
+func (s *Severity) UnmarshalText(text []byte) error {
+	*s, _ = ParseSeverity(string(text))
+	return nil
+}
+
+// Worst returns the most severe of the provided Severities, ignoring empty ones. If there
+// are none, an empty Severity is returned.
+func Worst(sevs ...Severity) Severity {
+	var worst Severity
+	for _, s := range sevs {
+		if s != "" && (worst == "" || s.WorseThan(worst)) {
+			worst = s
+		}
+	}
+	return worst
+}
+
+// Best returns the least severe of the provided Severities, ignoring empty ones. If there
+// are none, an empty Severity is returned.
+func Best(sevs ...Severity) Severity {
+	var best Severity
+	for _, s := range sevs {
+		if s != "" && (best == "" || best.WorseThan(s)) {
+			best = s
+		}
+	}
+	return best
+}
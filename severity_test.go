@@ -0,0 +1,77 @@
+package health
+
+import (
+	nagios "github.com/cognusion/go-nagios-checks"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"encoding/json"
+	"testing"
+)
+
+func Test_Severity(t *testing.T) {
+
+	Convey("When Severities are parsed, aliases are preserved and case is ignored", t, func() {
+		for in, out := range map[string]Severity{
+			"ok":       OK,
+			"Up":       UP,
+			"pass":     OK,
+			"warn":     WARNING,
+			"WARNING":  WARNING,
+			"bad":      BAD,
+			"Error":    ERROR,
+			"down":     DOWN,
+			"fail":     CRITICAL,
+			" unknown": UNKNOWN,
+		} {
+			s, err := ParseSeverity(in)
+			So(err, ShouldBeNil)
+			So(s, ShouldEqual, out)
+		}
+
+		s, err := ParseSeverity("")
+		So(err, ShouldBeNil)
+		So(s, ShouldBeEmpty)
+
+		s, err = ParseSeverity("sideways")
+		So(err, ShouldNotBeNil)
+		So(s, ShouldEqual, UNKNOWN)
+	})
+
+	Convey("When Severities are compared, they are ordered by how severe they are", t, func() {
+		So(UP.Canonical(), ShouldEqual, OK)
+		So(DOWN.Canonical(), ShouldEqual, CRITICAL)
+		So(Severity("").Canonical(), ShouldEqual, UNKNOWN)
+
+		So(UNKNOWN.WorseThan(OK), ShouldBeTrue)
+		So(WARNING.WorseThan(UNKNOWN), ShouldBeTrue)
+		So(BAD.WorseThan(WARNING), ShouldBeTrue)
+		So(ERROR.WorseThan(CRITICAL), ShouldBeFalse)
+		So(UP.IsOK(), ShouldBeTrue)
+		So(WARNING.IsOK(), ShouldBeFalse)
+
+		So(Worst(OK, "", WARNING, UP), ShouldEqual, WARNING)
+		So(Worst(UNKNOWN, DOWN, WARNING), ShouldEqual, DOWN)
+		So(Worst(), ShouldBeEmpty)
+		So(Best(CRITICAL, "", UNKNOWN, WARNING), ShouldEqual, UNKNOWN)
+		So(Best(), ShouldBeEmpty)
+	})
+
+	Convey("When Severities are mapped to Nagios codes, they are correct", t, func() {
+		So(UP.NagiosCode(), ShouldEqual, nagios.OK)
+		So(WARNING.NagiosCode(), ShouldEqual, nagios.WARNING)
+		So(ERROR.NagiosCode(), ShouldEqual, nagios.CRITICAL)
+		So(Severity("sideways").NagiosCode(), ShouldEqual, nagios.UNKNOWN)
+	})
+
+	Convey("When Severities are JSON encoded and decoded, the wire format is unchanged", t, func() {
+		b, err := json.Marshal(map[string]Severity{"status": DOWN})
+		So(err, ShouldBeNil)
+		So(string(b), ShouldEqual, `{"status":"DOWN"}`)
+
+		var m map[string]Severity
+		So(json.Unmarshal([]byte(`{"a":"warn","b":"sideways","c":""}`), &m), ShouldBeNil)
+		So(m["a"], ShouldEqual, WARNING)
+		So(m["b"], ShouldEqual, UNKNOWN)
+		So(m["c"], ShouldBeEmpty)
+	})
+}
@@ -5,6 +5,7 @@ import (
 
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -21,8 +22,18 @@ const (
 	ERROR    = StatusString("ERROR")
 )
 
-// StatusString is a string type for static string consistency
-type StatusString = string
+// Importance constants, to declare how much a Status matters to the overall status
+const (
+	// ImportanceRequired Statuses affect the overall status fully, and is the default
+	ImportanceRequired = "required"
+	// ImportanceDegraded Statuses can at worst degrade the overall status to WARNING
+	ImportanceDegraded = "degraded"
+	// ImportanceInformational Statuses never affect the overall status
+	ImportanceInformational = "informational"
+)
+
+// StatusString is an alias of Severity, for static string consistency
+type StatusString = Severity
 
 // Status is a type used to convey status-related information about a Service, System, or Metric
 type Status struct {
@@ -30,7 +41,7 @@ type Status struct {
 	Name string `json:"name,omitempty"`
 	// Status is universally required, one of: OK,WARNING,
 	// BAD/ERROR/CRITICAL, or UNKNOWN
-	Status string `json:"status,omitempty"`
+	Status Severity `json:"status,omitempty"`
 	// Value is optional for all but Metrics, and is used to convey a
 	// numeric-type representation
 	Value interface{} `json:"value,omitempty"`
@@ -43,14 +54,37 @@ type Status struct {
 	// BadOver is ony for Metrics, and is used to represent the Value at which a
 	// CRITICAL state will be triggered
 	BadOver interface{} `json:"badOver,omitempty"`
+	// WarnUnder is only for Metrics, and is used to represent the Value under which a
+	// WARNING state will be triggered
+	WarnUnder interface{} `json:"warnUnder,omitempty"`
+	// BadUnder is only for Metrics, and is used to represent the Value under which a
+	// CRITICAL state will be triggered
+	BadUnder interface{} `json:"badUnder,omitempty"`
+	// MinValue is optional, and is used to represent the lowest possible Value of a
+	// Metric (graph floor)
+	MinValue interface{} `json:"minValue,omitempty"`
+	// MaxValue is optional, and is used to represent the highest possible Value of a
+	// Metric (graph ceiling)
+	MaxValue interface{} `json:"maxValue,omitempty"`
 	// TimeStamp is optional, and is used to convey the time the Status or Value
 	// was retrieved
 	TimeStamp *time.Time `json:"timestamp,omitempty"`
 	// TimeOut is optional, and is used to set the amount of time TimeStamp can
 	// drift before it is considered stale, tiggering an alert
 	TimeOut *time.Duration `json:"timeout,omitempty"`
-	// Suffix is optional, and is used appended to Value for metrics
+	// Suffix is optional, and is used appended to Value for metrics. Only valid
+	// Nagios units of measure (see ValidUOM) are included in MetricString
 	Suffix string `json:"suffix,omitempty"`
+	// Stale is set by Check.Calculate when TimeStamp has drifted beyond TimeOut
+	// (or Check.MaxAge), and is used to convey that the Status is out of date
+	Stale bool `json:"stale,omitempty"`
+	// Importance is optional, and is used to declare how much the Status matters to the
+	// overall status, one of: required (the default), degraded, or informational
+	Importance string `json:"importance,omitempty"`
+	// Message is optional, and is used to explain why the Status is what it is
+	Message string `json:"message,omitempty"`
+	// Error is optional, and is used to convey the error that caused the Status, if any
+	Error string `json:"error,omitempty"`
 }
 
 // rawStatus is the Status struct without the higher-level time.Time and time.Duration used in
@@ -60,7 +94,7 @@ type rawStatus struct {
 	Name string `json:"name,omitempty"`
 	// Status is universally required, one of: OK,WARNING,
 	// BAD/ERROR/CRITICAL, or UNKNOWN
-	Status string `json:"status,omitempty"`
+	Status Severity `json:"status,omitempty"`
 	// Value is optional for all but Metrics, and is used to convey a
 	// numeric-type representation
 	Value interface{} `json:"value,omitempty"`
@@ -73,14 +107,37 @@ type rawStatus struct {
 	// BadOver is ony for Metrics, and is used to represent the Value at which a
 	// CRITICAL state will be triggered
 	BadOver interface{} `json:"badOver,omitempty"`
+	// WarnUnder is only for Metrics, and is used to represent the Value under which a
+	// WARNING state will be triggered
+	WarnUnder interface{} `json:"warnUnder,omitempty"`
+	// BadUnder is only for Metrics, and is used to represent the Value under which a
+	// CRITICAL state will be triggered
+	BadUnder interface{} `json:"badUnder,omitempty"`
+	// MinValue is optional, and is used to represent the lowest possible Value of a
+	// Metric (graph floor)
+	MinValue interface{} `json:"minValue,omitempty"`
+	// MaxValue is optional, and is used to represent the highest possible Value of a
+	// Metric (graph ceiling)
+	MaxValue interface{} `json:"maxValue,omitempty"`
 	// TimeStamp is optional, and is used to convey the time the Status or Value
 	// was retrieved
 	TimeStamp *int64 `json:"timestamp,omitempty"`
 	// TimeOut is optional, and is used to set the amount of time TimeStamp can
 	// drift before it is considered stale, tiggering an alert
 	TimeOut *int64 `json:"timeout,omitempty"`
-	// Suffix is optional, and is used appended to Value for metrics
+	// Suffix is optional, and is used appended to Value for metrics. Only valid
+	// Nagios units of measure (see ValidUOM) are included in MetricString
 	Suffix string `json:"suffix,omitempty"`
+	// Stale is set by Check.Calculate when TimeStamp has drifted beyond TimeOut
+	// (or Check.MaxAge), and is used to convey that the Status is out of date
+	Stale bool `json:"stale,omitempty"`
+	// Importance is optional, and is used to declare how much the Status matters to the
+	// overall status, one of: required (the default), degraded, or informational
+	Importance string `json:"importance,omitempty"`
+	// Message is optional, and is used to explain why the Status is what it is
+	Message string `json:"message,omitempty"`
+	// Error is optional, and is used to convey the error that caused the Status, if any
+	Error string `json:"error,omitempty"`
 }
 
 // MarshalJSON is a custom marshaller for JSON encoding,
@@ -93,7 +150,15 @@ func (s *Status) MarshalJSON() ([]byte, error) {
 		ExpectedValue: s.ExpectedValue,
 		WarnOver:      s.WarnOver,
 		BadOver:       s.BadOver,
+		WarnUnder:     s.WarnUnder,
+		BadUnder:      s.BadUnder,
+		MinValue:      s.MinValue,
+		MaxValue:      s.MaxValue,
 		Suffix:        s.Suffix,
+		Stale:         s.Stale,
+		Importance:    s.Importance,
+		Message:       s.Message,
+		Error:         s.Error,
 	}
 
 	if s.TimeStamp != nil {
@@ -112,14 +177,77 @@ func (s *Status) MarshalJSON() ([]byte, error) {
 // MetricString returns a Nagios Performance Data -compatible representation of Status
 func (s *Status) MetricString() string {
 	value := cast.ToString(s.Value)
-	if s.Suffix != "" {
+	if _, ok := ParseGeoPoint(s.Value); ok {
+		// GeoPoints are represented by their distance from ExpectedValue, in km
+		value = "U"
+		if d, ok := s.geoDistance(); ok {
+			value = strconv.FormatFloat(d, 'f', 3, 64)
+		}
+	} else if ValidUOM(s.Suffix) {
 		value = fmt.Sprintf("%s%s", value, s.Suffix)
 	}
 
-	// note we are missing min and max
 	return fmt.Sprintf("'%s'=%s;%s;%s;%s;%s", s.Name,
-		value, cast.ToString(s.WarnOver),
-		cast.ToString(s.BadOver), "", "")
+		value, perfThreshold(s.WarnOver, s.WarnUnder),
+		perfThreshold(s.BadOver, s.BadUnder),
+		cast.ToString(s.MinValue), cast.ToString(s.MaxValue))
+}
+
+// thresholdValue returns the value of the Status that thresholds apply to: the Value if it
+// is numeric, or the distance from ExpectedValue if it is a GeoPoint. The second return is
+// false if there is no such value.
+func (s *Status) thresholdValue() (float64, bool) {
+	if _, ok := ParseGeoPoint(s.Value); ok {
+		return s.geoDistance()
+	}
+	return isNumericGimme(cast.ToString(s.Value))
+}
+
+// valueStatus returns the status of the Status according to its thresholds, OK if none are
+// breached, or an empty Severity if there is no value to compare against them
+func (s *Status) valueStatus() Severity {
+	v, ok := s.thresholdValue()
+	if !ok {
+		return ""
+	}
+	if status := thresholdStatus(v, s.WarnOver, s.BadOver, s.WarnUnder, s.BadUnder); status != "" {
+		return status
+	}
+	return OK
+}
+
+// ValidUOM returns true if the unit is a valid Nagios Performance Data unit of measure:
+// s, ms, us, %, B, KB, MB, GB, TB, or c
+func ValidUOM(unit string) bool {
+	switch unit {
+	case "s", "ms", "us", "%", "B", "KB", "MB", "GB", "TB", "c":
+		return true
+	}
+	return false
+}
+
+// importanceLimit returns the worst status the Status may contribute to an overall status
+// according to its Importance, or an empty Severity if it may contribute nothing
+func (s *Status) importanceLimit() Severity {
+	switch strings.ToLower(s.Importance) {
+	case ImportanceDegraded:
+		return WARNING
+	case ImportanceInformational:
+		return ""
+	}
+	return CRITICAL
+}
+
+// isStale returns true if the Status TimeStamp is older than its TimeOut, or maxAge if it
+// has no TimeOut, as of now. The second return is false if staleness cannot be determined.
+func (s *Status) isStale(now time.Time, maxAge time.Duration) (stale, ok bool) {
+	if s.TimeOut != nil && *s.TimeOut > 0 {
+		maxAge = *s.TimeOut
+	}
+	if s.TimeStamp == nil || maxAge <= 0 {
+		return false, false
+	}
+	return now.Sub(*s.TimeStamp) > maxAge, true
 }
 
 // StatusSliceFromJmap is a hacky function that might take a slice of interfaces, and return a same-sized slice of Status
@@ -135,7 +263,7 @@ func StatusSliceFromJmap(jmap []interface{}) []Status {
 			to *time.Duration
 		)
 
-		if m, ok := jr["timestamp"]; ok {
+		if m, ok := jr["timestamp"]; ok && cast.ToInt64(m) != 0 {
 
 			mi := cast.ToInt64(m)
 			tsx := cast.ToTime(mi)
@@ -149,8 +277,14 @@ func StatusSliceFromJmap(jmap []interface{}) []Status {
 			ts = &tsx
 		}
 
-		if m, ok := jr["timeout"]; ok {
-			tox := cast.ToDuration(m)
+		if m, ok := jr["timeout"]; ok && m != nil {
+			var tox time.Duration
+			if ms, ok := isNumericGimme(cast.ToString(m)); ok {
+				// Numeric timeouts are milliseconds, as MarshalJSON writes them
+				tox = time.Duration(ms * float64(time.Millisecond))
+			} else {
+				tox = cast.ToDuration(m)
+			}
 			to = &tox
 		}
 
@@ -162,15 +296,27 @@ func StatusSliceFromJmap(jmap []interface{}) []Status {
 			jr["badover"] = m
 		}
 
+		// Unrecognized statuses are UNKNOWN
+		status, _ := ParseSeverity(cast.ToString(jr["status"]))
+
 		s := Status{
 			Name:          cast.ToString(jr["name"]),
-			Status:        cast.ToString(jr["status"]),
-			Value:         jr["value"],
-			ExpectedValue: jr["expectedvalue"],
+			Status:        status,
+			Value:         geoValue(jr["value"]),
+			ExpectedValue: geoValue(jr["expectedvalue"]),
 			WarnOver:      jr["warnover"],
 			BadOver:       jr["badover"],
+			WarnUnder:     jr["warnunder"],
+			BadUnder:      jr["badunder"],
+			MinValue:      jr["minvalue"],
+			MaxValue:      jr["maxvalue"],
 			TimeStamp:     ts,
 			TimeOut:       to,
+			Suffix:        cast.ToString(jr["suffix"]),
+			Stale:         cast.ToBool(jr["stale"]),
+			Importance:    strings.ToLower(cast.ToString(jr["importance"])),
+			Message:       cast.ToString(jr["message"]),
+			Error:         cast.ToString(jr["error"]),
 		}
 		statuses[c] = s
 		c++
@@ -2,58 +2,251 @@ package health
 
 import (
 	"errors"
+	"reflect"
+	"sort"
 	"sync"
+	"time"
 )
 
 var (
 	// ErrNoSuchEntryError is returned when the requested element does not exist in the Registry
 	ErrNoSuchEntryError = errors.New("no such element exists")
+	// ErrNoNameError is returned when a Status without a Name is Set in the Registry
+	ErrNoNameError = errors.New("status has no name")
 )
 
-// StatusRegistry is a gorosafe map of services to their Status objects
+// Category is the section of a Check that a StatusRegistry entry belongs in
+type Category string
+
+// Category constants for StatusRegistry entries
+const (
+	ServiceCategory = Category("service")
+	SystemCategory  = Category("system")
+	MetricCategory  = Category("metric")
+)
+
+// registryEntry is a Status and the Category it belongs to
+type registryEntry struct {
+	status   Status
+	category Category
+	// expired is true if the entry has been marked UNKNOWN by Expire, and
+	// unexpired is its status before then
+	expired   bool
+	unexpired Severity
+}
+
+// StatusRegistry is a gorosafe map of services to their Status objects. Entries are named by
+// the SafeLabel of the name they are added with, which is also the Name of their Status, and
+// may be looked up by either. Changes can be received via Watch or Subscribe.
 type StatusRegistry struct {
 	sync.RWMutex
-	stats map[string]Status
+	stats map[string]registryEntry
+	subs  map[*Subscription]struct{}
 }
 
 // NewStatusRegistry returns an initialized StatusRegistry
 func NewStatusRegistry() *StatusRegistry {
 	return &StatusRegistry{
-		stats: make(map[string]Status),
+		stats: make(map[string]registryEntry),
 	}
 }
 
-// Add or update an entry in StatusRegistry
-func (s *StatusRegistry) Add(name, status string, Value, ExpectedValue interface{}) {
-	sname := SafeLabel(name)
-	s.Lock()
-	stat := Status{
-		Name:          sname,
+// Add or update an entry in StatusRegistry. New entries are in the ServiceCategory,
+// existing entries retain their Category. The status is parsed as by ParseSeverity.
+func (s *StatusRegistry) Add(name string, status Severity, Value, ExpectedValue interface{}) {
+	s.AddCategorized("", name, status, Value, ExpectedValue)
+}
+
+// AddCategorized adds or updates an entry in StatusRegistry, in the specified Category.
+// If category is empty, it is treated as it is in Add.
+func (s *StatusRegistry) AddCategorized(category Category, name string, status Severity, Value, ExpectedValue interface{}) {
+	s.set(category, name, Status{
 		Status:        status,
 		Value:         Value,
 		ExpectedValue: ExpectedValue,
+	})
+}
+
+// SetMessage sets the Message of an existing entry in StatusRegistry, or returns ErrNoSuchEntryError
+func (s *StatusRegistry) SetMessage(name, message string) error {
+	return s.update(name, func(e *registryEntry, _ time.Time) {
+		e.status.Message = message
+	})
+}
+
+// SetError sets the Error of an existing entry in StatusRegistry, or returns ErrNoSuchEntryError.
+// A nil err clears the Error.
+func (s *StatusRegistry) SetError(name string, err error) error {
+	return s.update(name, func(e *registryEntry, _ time.Time) {
+		e.status.Error = ""
+		if err != nil {
+			e.status.Error = err.Error()
+		}
+	})
+}
+
+// Set adds or updates an entry in StatusRegistry with a complete Status, named by its Name,
+// or returns ErrNoNameError. New entries are in the ServiceCategory, existing entries retain
+// their Category. The status is parsed as by ParseSeverity.
+func (s *StatusRegistry) Set(stat Status) error {
+	return s.SetCategorized("", stat)
+}
+
+// SetCategorized adds or updates an entry in StatusRegistry with a complete Status, in the
+// specified Category, or returns ErrNoNameError. If category is empty, it is treated as it is in Set.
+func (s *StatusRegistry) SetCategorized(category Category, stat Status) error {
+	if stat.Name == "" {
+		return ErrNoNameError
+	}
+	s.set(category, stat.Name, stat)
+	return nil
+}
+
+// SetMany adds or updates entries in StatusRegistry as Set does, all at once. If any Status
+// has no Name, ErrNoNameError is returned and nothing is changed.
+func (s *StatusRegistry) SetMany(stats ...Status) error {
+	for i := range stats {
+		if stats[i].Name == "" {
+			return ErrNoNameError
+		}
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	for _, stat := range stats {
+		s.setLocked("", stat.Name, stat)
+	}
+	return nil
+}
+
+// Update calls f with the Status of an existing entry in StatusRegistry, with the lock held,
+// so the read-modify-write is atomic. Returns ErrNoSuchEntryError if there is no such entry.
+// The entry cannot be renamed, the status is parsed as by ParseSeverity, and f must not call
+// other StatusRegistry functions.
+func (s *StatusRegistry) Update(name string, f func(*Status)) error {
+	return s.update(name, func(e *registryEntry, _ time.Time) {
+		status := e.status.Status
+		f(&e.status)
+		e.status.Status, _ = ParseSeverity(string(e.status.Status))
+		if e.status.Status != status {
+			// No longer the status Expire set
+			e.expired = false
+		}
+	})
+}
+
+// CompareAndSwap replaces the Status of an existing entry in StatusRegistry with new, only if
+// the current Status is deeply equal to old, and returns true if it did so.
+func (s *StatusRegistry) CompareAndSwap(name string, old, new Status) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	e, ok := s.stats[SafeLabel(name)]
+	if !ok || !reflect.DeepEqual(e.status, old) {
+		return false
 	}
-	s.stats[name] = stat
-	s.Unlock()
+	s.setLocked("", name, new)
+	return true
+}
+
+// Range calls f with the Status and Category of every entry in StatusRegistry, sorted by name,
+// until f returns false. The lock is held, so f must not call other StatusRegistry functions.
+func (s *StatusRegistry) Range(f func(stat Status, category Category) bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	for _, k := range s.keys() {
+		e := s.stats[k]
+		if !f(e.status, e.category) {
+			return
+		}
+	}
+}
+
+// set adds or updates an entry in StatusRegistry with a complete Status.
+// If category is empty, it is treated as it is in Add.
+func (s *StatusRegistry) set(category Category, name string, stat Status) {
+	s.Lock()
+	defer s.Unlock()
+	s.setLocked(category, name, stat)
+}
+
+// setLocked is set. Must be called with the lock held.
+func (s *StatusRegistry) setLocked(category Category, name string, stat Status) {
+	name = SafeLabel(name)
+	stat.Name = name
+	stat.Status, _ = ParseSeverity(string(stat.Status))
+
+	var old *Status
+	if e, ok := s.stats[name]; ok {
+		old = copyStatus(e.status)
+		if category == "" {
+			category = e.category
+		}
+	} else if category == "" {
+		category = ServiceCategory
+	}
+	s.stats[name] = registryEntry{
+		status:   stat,
+		category: category,
+	}
+	s.notify(name, old, copyStatus(stat))
+}
+
+// update calls f on an existing entry in StatusRegistry with the lock held, or returns
+// ErrNoSuchEntryError
+func (s *StatusRegistry) update(name string, f func(e *registryEntry, now time.Time)) error {
+	s.Lock()
+	defer s.Unlock()
+
+	name = SafeLabel(name)
+	e, ok := s.stats[name]
+	if !ok {
+		return ErrNoSuchEntryError
+	}
+	old := e.status
+	f(&e, time.Now())
+	e.status.Name = name
+	e.status.Status, _ = ParseSeverity(string(e.status.Status))
+	s.stats[name] = e
+	s.notify(name, &old, copyStatus(e.status))
+	return nil
+}
+
+// copyStatus returns a pointer to a copy of the Status
+func copyStatus(stat Status) *Status {
+	return &stat
 }
 
 // Remove an entry from the StatusRegistry
 func (s *StatusRegistry) Remove(name string) {
 	s.Lock()
-	delete(s.stats, name)
-	s.Unlock()
+	defer s.Unlock()
+
+	name = SafeLabel(name)
+	if e, ok := s.stats[name]; ok {
+		delete(s.stats, name)
+		s.notify(name, copyStatus(e.status), nil)
+	}
 }
 
-// Keys returns a list of names from the StatusRegistry
+// Keys returns a sorted list of names from the StatusRegistry
 func (s *StatusRegistry) Keys() []string {
+	s.RLock()
+	defer s.RUnlock()
+	return s.keys()
+}
+
+// keys returns a sorted list of names from the StatusRegistry. Must be called
+// with the lock held.
+func (s *StatusRegistry) keys() []string {
 	keys := make([]string, len(s.stats))
 	i := 0
-	s.RLock()
 	for k := range s.stats {
 		keys[i] = k
 		i++
 	}
-	s.RUnlock()
+	sort.Strings(keys)
 	return keys
 }
 
@@ -62,8 +255,43 @@ func (s *StatusRegistry) Get(name string) (*Status, error) {
 	s.RLock()
 	defer s.RUnlock()
 
-	if stat, ok := s.stats[name]; ok {
+	if e, ok := s.stats[SafeLabel(name)]; ok {
+		stat := e.status
 		return &stat, nil
 	}
 	return nil, ErrNoSuchEntryError
 }
+
+// Category returns the Category of the requested entry, or ErrNoSuchEntryError
+func (s *StatusRegistry) Category(name string) (Category, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	if e, ok := s.stats[SafeLabel(name)]; ok {
+		return e.category, nil
+	}
+	return "", ErrNoSuchEntryError
+}
+
+// Snapshot returns a Calculate()d Check of every entry in the StatusRegistry, each in
+// the section appropriate for its Category. Entries are sorted by name, so the output is stable.
+func (s *StatusRegistry) Snapshot() Check {
+	hc := NewCheck()
+
+	s.RLock()
+	for _, k := range s.keys() {
+		e := s.stats[k]
+		switch e.category {
+		case SystemCategory:
+			hc.AddSystem(&e.status)
+		case MetricCategory:
+			hc.AddMetric(&e.status)
+		default:
+			hc.AddService(&e.status)
+		}
+	}
+	s.RUnlock()
+
+	hc.Calculate()
+	return hc
+}
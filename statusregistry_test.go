@@ -3,6 +3,8 @@ package health
 import (
 	. "github.com/smartystreets/goconvey/convey"
 
+	"errors"
+	"sync"
 	"testing"
 )
 
@@ -28,3 +30,196 @@ func Test_StatusRegistry(t *testing.T) {
 
 	})
 }
+
+func Test_StatusRegistrySnapshot(t *testing.T) {
+
+	Convey("When a StatusRegistry has entries in every Category, Snapshot returns a sorted, Calculated Check", t, func() {
+		sr := NewStatusRegistry()
+		sr.Add("zeta", OK, nil, nil)
+		sr.Add("alpha", OK, nil, nil)
+		sr.AddCategorized(SystemCategory, "disk", WARNING, nil, nil)
+		sr.AddCategorized(MetricCategory, "load", "", 1.5, nil)
+
+		So(sr.Keys(), ShouldResemble, []string{"alpha", "disk", "load", "zeta"})
+
+		cat, err := sr.Category("disk")
+		So(err, ShouldBeNil)
+		So(cat, ShouldEqual, SystemCategory)
+
+		_, err = sr.Category("nope")
+		So(err, ShouldEqual, ErrNoSuchEntryError)
+
+		hc := sr.Snapshot()
+		So(hc.OverallStatus, ShouldEqual, WARNING)
+		So(len(hc.Services), ShouldEqual, 2)
+		So(hc.Services[0].Name, ShouldEqual, "alpha")
+		So(hc.Services[1].Name, ShouldEqual, "zeta")
+		So(len(hc.Systems), ShouldEqual, 1)
+		So(hc.Systems[0].Name, ShouldEqual, "disk")
+		So(len(hc.Metrics), ShouldEqual, 1)
+		So(hc.Metrics[0].Name, ShouldEqual, "load")
+
+		Convey("and re-Adding an entry retains its Category", func() {
+			sr.Add("disk", OK, nil, nil)
+			cat, err := sr.Category("disk")
+			So(err, ShouldBeNil)
+			So(cat, ShouldEqual, SystemCategory)
+
+			hc := sr.Snapshot()
+			So(hc.OverallStatus, ShouldEqual, OK)
+			hc2 := sr.Snapshot()
+			So(hc.JSON(), ShouldEqual, hc2.JSON())
+		})
+	})
+}
+
+func Test_StatusRegistryMessages(t *testing.T) {
+
+	Convey("When a StatusRegistry entry has a Message and Error set, they are retained", t, func() {
+		sr := NewStatusRegistry()
+		sr.Add("db", DOWN, nil, nil)
+
+		So(sr.SetMessage("db", "failing over"), ShouldBeNil)
+		So(sr.SetError("db", errors.New("connection refused")), ShouldBeNil)
+
+		stat, err := sr.Get("db")
+		So(err, ShouldBeNil)
+		So(stat.Message, ShouldEqual, "failing over")
+		So(stat.Error, ShouldEqual, "connection refused")
+
+		hc := sr.Snapshot()
+		So(hc.JSON(), ShouldContainSubstring, `"message":"failing over","error":"connection refused"`)
+
+		Convey("and a nil error clears the Error", func() {
+			So(sr.SetError("db", nil), ShouldBeNil)
+			stat, err := sr.Get("db")
+			So(err, ShouldBeNil)
+			So(stat.Error, ShouldBeEmpty)
+			So(stat.Message, ShouldEqual, "failing over")
+		})
+
+		Convey("and missing entries are errors", func() {
+			So(sr.SetMessage("nope", "hi"), ShouldEqual, ErrNoSuchEntryError)
+			So(sr.SetError("nope", nil), ShouldEqual, ErrNoSuchEntryError)
+		})
+	})
+}
+
+func Test_StatusRegistrySet(t *testing.T) {
+
+	Convey("When complete Statuses are Set in a StatusRegistry, everything is retained", t, func() {
+		sr := NewStatusRegistry()
+		So(sr.Set(Status{Name: "web-1.latency", Value: 250, Suffix: "ms", WarnOver: 200, Message: "slow"}), ShouldBeNil)
+		So(sr.Set(Status{Value: 1}), ShouldEqual, ErrNoNameError)
+
+		stat, err := sr.Get("web-1.latency")
+		So(err, ShouldBeNil)
+		So(stat.Name, ShouldEqual, "web_1:latency")
+		So(stat.Suffix, ShouldEqual, "ms")
+		So(stat.WarnOver, ShouldEqual, 200)
+
+		Convey("and entries are named by their SafeLabel, but found by either name", func() {
+			So(sr.Keys(), ShouldResemble, []string{"web_1:latency"})
+			_, err := sr.Get("web_1:latency")
+			So(err, ShouldBeNil)
+
+			sr.Add("web-1.latency", OK, nil, nil)
+			So(sr.Keys(), ShouldResemble, []string{"web_1:latency"})
+			sr.Remove("web-1.latency")
+			So(sr.Keys(), ShouldBeEmpty)
+		})
+
+		Convey("and SetCategorized sets the Category", func() {
+			So(sr.SetCategorized(MetricCategory, Status{Name: "web-1.latency", Value: 300}), ShouldBeNil)
+			cat, err := sr.Category("web-1.latency")
+			So(err, ShouldBeNil)
+			So(cat, ShouldEqual, MetricCategory)
+		})
+	})
+
+	Convey("When Statuses are written in any way, their status is parsed the same", t, func() {
+		sr := NewStatusRegistry()
+		sr.Add("added", "Critical", nil, nil)
+		So(sr.Set(Status{Name: "set", Status: "Critical"}), ShouldBeNil)
+		So(sr.SetCategorized(SystemCategory, Status{Name: "categorized", Status: "Critical"}), ShouldBeNil)
+		So(sr.SetMany(Status{Name: "many", Status: "Critical"}), ShouldBeNil)
+		So(sr.Set(Status{Name: "updated"}), ShouldBeNil)
+		So(sr.Update("updated", func(stat *Status) { stat.Status = "Critical" }), ShouldBeNil)
+		So(sr.Set(Status{Name: "bogus", Status: "sideways"}), ShouldBeNil)
+
+		for _, name := range []string{"added", "set", "categorized", "many", "updated"} {
+			stat, err := sr.Get(name)
+			So(err, ShouldBeNil)
+			So(stat.Status, ShouldEqual, CRITICAL)
+		}
+		stat, err := sr.Get("bogus")
+		So(err, ShouldBeNil)
+		So(stat.Status, ShouldEqual, UNKNOWN)
+	})
+
+	Convey("When many Statuses are Set at once, all or none are", t, func() {
+		sr := NewStatusRegistry()
+		So(sr.SetMany(Status{Name: "a", Status: OK}, Status{Status: OK}), ShouldEqual, ErrNoNameError)
+		So(sr.Keys(), ShouldBeEmpty)
+
+		So(sr.SetMany(Status{Name: "a", Status: OK}, Status{Name: "b", Status: DOWN}), ShouldBeNil)
+		So(sr.Keys(), ShouldResemble, []string{"a", "b"})
+
+		Convey("and Range visits them in order, until told to stop", func() {
+			var names []string
+			sr.Range(func(stat Status, category Category) bool {
+				So(category, ShouldEqual, ServiceCategory)
+				names = append(names, stat.Name)
+				return true
+			})
+			So(names, ShouldResemble, []string{"a", "b"})
+
+			names = nil
+			sr.Range(func(stat Status, category Category) bool {
+				names = append(names, stat.Name)
+				return false
+			})
+			So(names, ShouldResemble, []string{"a"})
+		})
+	})
+
+	Convey("When a StatusRegistry entry is Updated concurrently, no updates are lost", t, func() {
+		sr := NewStatusRegistry()
+		So(sr.Set(Status{Name: "counter", Value: 0}), ShouldBeNil)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sr.Update("counter", func(st *Status) {
+					st.Value = st.Value.(int) + 1
+					st.Name = "renamed"
+				})
+			}()
+		}
+		wg.Wait()
+
+		stat, err := sr.Get("counter")
+		So(err, ShouldBeNil)
+		So(stat.Value, ShouldEqual, 50)
+		So(stat.Name, ShouldEqual, "counter")
+		So(sr.Update("nope", func(*Status) {}), ShouldEqual, ErrNoSuchEntryError)
+	})
+
+	Convey("When a StatusRegistry entry is CompareAndSwapped, it is only swapped if unchanged", t, func() {
+		sr := NewStatusRegistry()
+		sr.Add("db", OK, nil, nil)
+		old, err := sr.Get("db")
+		So(err, ShouldBeNil)
+
+		So(sr.CompareAndSwap("db", *old, Status{Status: CRITICAL}), ShouldBeTrue)
+		So(sr.CompareAndSwap("db", *old, Status{Status: WARNING}), ShouldBeFalse)
+		So(sr.CompareAndSwap("nope", *old, Status{Status: WARNING}), ShouldBeFalse)
+
+		stat, err := sr.Get("db")
+		So(err, ShouldBeNil)
+		So(stat.Status, ShouldEqual, CRITICAL)
+		So(stat.Name, ShouldEqual, "db")
+	})
+}
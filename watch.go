@@ -0,0 +1,169 @@
+package health
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultWatchBuffer is the number of StatusEvents buffered for a Subscription, if
+// none is specified
+const DefaultWatchBuffer = 64
+
+// StatusEvent is a change to an entry in a StatusRegistry. The Statuses are copies, shared
+// by every Subscription that receives the event, and should not be modified.
+type StatusEvent struct {
+	// Name is the name of the entry in the StatusRegistry
+	Name string
+	// Old is the previous Status, or nil if the entry was added
+	Old *Status
+	// New is the current Status, or nil if the entry was removed
+	New *Status
+	// Time is when the change happened
+	Time time.Time
+}
+
+// Transition returns true if the event changed the status of the entry, including
+// adding or removing it
+func (e *StatusEvent) Transition() bool {
+	if e.Old == nil || e.New == nil {
+		return true
+	}
+	return effectiveStatus(e.Old) != effectiveStatus(e.New)
+}
+
+// WatchFilter selects the StatusEvents that a Subscription receives. The zero
+// WatchFilter receives every Transition.
+type WatchFilter struct {
+	// Prefix, if set, only matches entries whose names begin with its SafeLabel
+	Prefix string
+	// MinSeverity, if set, only matches events where the old or new status is at
+	// least that severe, so recoveries are matched as well as failures
+	MinSeverity Severity
+	// AllUpdates matches every change to an entry, not just Transitions
+	AllUpdates bool
+}
+
+// match returns true if the StatusEvent passes the WatchFilter
+func (f *WatchFilter) match(e *StatusEvent) bool {
+	if !strings.HasPrefix(e.Name, f.Prefix) {
+		return false
+	}
+	if !f.AllUpdates && !e.Transition() {
+		return false
+	}
+	if f.MinSeverity != "" {
+		for _, st := range []*Status{e.Old, e.New} {
+			if st != nil && !f.MinSeverity.WorseThan(effectiveStatus(st)) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Subscription receives StatusEvents from a StatusRegistry. Delivery never blocks the
+// StatusRegistry: events that do not fit in the buffer are dropped, and counted.
+type Subscription struct {
+	// dropped is first, to be 64-bit aligned for atomic operations
+	dropped uint64
+
+	// C delivers the StatusEvents. It is closed when the Subscription is Closed. For
+	// Subscriptions created with Subscribe, it is drained by the callback and must not be read.
+	C <-chan StatusEvent
+
+	filter   WatchFilter
+	ch       chan StatusEvent
+	registry *StatusRegistry
+}
+
+// Watch returns a Subscription delivering matching StatusEvents over its channel, C, with
+// room for buffer events. If buffer is less than 1, DefaultWatchBuffer is used.
+func (s *StatusRegistry) Watch(filter WatchFilter, buffer int) *Subscription {
+	if buffer < 1 {
+		buffer = DefaultWatchBuffer
+	}
+	// Entries are named by their SafeLabel
+	filter.Prefix = SafeLabel(filter.Prefix)
+
+	ch := make(chan StatusEvent, buffer)
+	sub := &Subscription{
+		C:        ch,
+		filter:   filter,
+		ch:       ch,
+		registry: s,
+	}
+
+	s.Lock()
+	if s.subs == nil {
+		s.subs = make(map[*Subscription]struct{})
+	}
+	s.subs[sub] = struct{}{}
+	s.Unlock()
+	return sub
+}
+
+// Subscribe returns a Subscription that calls f with each matching StatusEvent, in order,
+// from its own goroutine. Events that arrive faster than f can handle them are dropped once
+// DefaultWatchBuffer are waiting.
+func (s *StatusRegistry) Subscribe(filter WatchFilter, f func(StatusEvent)) *Subscription {
+	sub := s.Watch(filter, DefaultWatchBuffer)
+	go func() {
+		for e := range sub.C {
+			f(e)
+		}
+	}()
+	return sub
+}
+
+// Dropped returns the number of StatusEvents that were dropped because the buffer was full
+func (sub *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&sub.dropped)
+}
+
+// Close stops delivery of StatusEvents and closes C. Close may be called more than once.
+func (sub *Subscription) Close() {
+	s := sub.registry
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.subs[sub]; ok {
+		delete(s.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// notify delivers a StatusEvent for the change to every matching Subscription, without
+// blocking. Must be called with the lock held.
+func (s *StatusRegistry) notify(name string, old, new *Status) {
+	if len(s.subs) == 0 {
+		return
+	}
+
+	e := StatusEvent{
+		Name: name,
+		Old:  old,
+		New:  new,
+		Time: time.Now(),
+	}
+	for sub := range s.subs {
+		if !sub.filter.match(&e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			atomic.AddUint64(&sub.dropped, 1)
+		}
+	}
+}
+
+// effectiveStatus returns the declared status of the Status, or its status according to
+// its thresholds if none is declared
+func effectiveStatus(st *Status) Severity {
+	if st.Status != "" {
+		return st.Status.Canonical()
+	}
+	return st.valueStatus()
+}
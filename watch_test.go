@@ -0,0 +1,111 @@
+package health
+
+import (
+	. "github.com/smartystreets/goconvey/convey"
+
+	"errors"
+	"testing"
+	"time"
+)
+
+func Test_Watch(t *testing.T) {
+
+	Convey("When a StatusRegistry is Watched, transitions are delivered", t, func() {
+		sr := NewStatusRegistry()
+		sub := sr.Watch(WatchFilter{}, 10)
+		defer sub.Close()
+
+		sr.Add("db", OK, nil, nil)
+		sr.Add("db", UP, nil, nil)
+		sr.Add("db", CRITICAL, nil, nil)
+		So(sr.SetError("db", errors.New("connection refused")), ShouldBeNil)
+		sr.Remove("db")
+		sr.Remove("db")
+
+		So(len(sub.C), ShouldEqual, 3)
+
+		e := <-sub.C
+		So(e.Name, ShouldEqual, "db")
+		So(e.Old, ShouldBeNil)
+		So(e.New.Status, ShouldEqual, OK)
+		So(e.Time, ShouldNotBeZeroValue)
+
+		e = <-sub.C
+		So(e.Old.Status, ShouldEqual, UP)
+		So(e.New.Status, ShouldEqual, CRITICAL)
+		So(e.Transition(), ShouldBeTrue)
+
+		e = <-sub.C
+		So(e.Old.Error, ShouldEqual, "connection refused")
+		So(e.New, ShouldBeNil)
+		So(sub.Dropped(), ShouldEqual, 0)
+	})
+
+	Convey("When a Watch is filtered, only matching events are delivered", t, func() {
+		sr := NewStatusRegistry()
+		all := sr.Watch(WatchFilter{Prefix: "web-", AllUpdates: true}, 10)
+		defer all.Close()
+		bad := sr.Watch(WatchFilter{MinSeverity: CRITICAL}, 10)
+		defer bad.Close()
+
+		sr.Add("db", WARNING, nil, nil)
+		sr.Add("web-1", OK, nil, nil)
+		So(sr.SetMessage("web-1", "warming up"), ShouldBeNil)
+		sr.Add("web-1", DOWN, nil, nil)
+		sr.Add("web-1", OK, nil, nil)
+		sr.AddCategorized(MetricCategory, "heap", "", 95, nil)
+
+		So(len(all.C), ShouldEqual, 4)
+		So(len(bad.C), ShouldEqual, 2)
+
+		e := <-bad.C
+		So(e.New.Status, ShouldEqual, DOWN)
+		e = <-bad.C
+		So(e.Old.Status, ShouldEqual, DOWN)
+		So(e.New.Status, ShouldEqual, OK)
+	})
+
+	Convey("When a Watcher is slow, events are dropped and counted instead of blocking", t, func() {
+		sr := NewStatusRegistry()
+		sub := sr.Watch(WatchFilter{AllUpdates: true}, 2)
+
+		for i := 0; i < 5; i++ {
+			sr.Add("db", OK, i, nil)
+		}
+		So(len(sub.C), ShouldEqual, 2)
+		So(sub.Dropped(), ShouldEqual, 3)
+
+		Convey("and Closing closes the channel, and stops delivery", func() {
+			sub.Close()
+			sub.Close()
+			sr.Add("db", CRITICAL, nil, nil)
+
+			n := 0
+			for range sub.C {
+				n++
+			}
+			So(n, ShouldEqual, 2)
+		})
+	})
+
+	Convey("When a StatusRegistry is Subscribed to, the callback is called with each event", t, func() {
+		sr := NewStatusRegistry()
+		events := make(chan StatusEvent, 10)
+		sub := sr.Subscribe(WatchFilter{}, func(e StatusEvent) {
+			events <- e
+		})
+		defer sub.Close()
+
+		sr.Add("db", OK, nil, nil)
+		sr.Add("db", CRITICAL, nil, nil)
+
+		for _, want := range []Severity{OK, CRITICAL} {
+			select {
+			case e := <-events:
+				So(e.New.Status, ShouldEqual, want)
+			case <-time.After(time.Second):
+				So("timed out", ShouldBeEmpty)
+			}
+		}
+	})
+}